@@ -0,0 +1,302 @@
+package gendry
+
+import "io"
+import "fmt"
+import "math"
+import "regexp"
+import "strings"
+import "text/template"
+
+const (
+	badgeLabelColor     = "#555"
+	badgeFontFamily     = "Verdana,Geneva,DejaVu Sans,sans-serif"
+	badgeSparklineWidth = 40
+	badgeSparklineInset = 4
+)
+
+// verdanaWidths holds the rendered width (in pixels) of printable ascii characters in 11px verdana, the font used by
+// every badge style. Characters outside of this table are measured using verdanaDefaultWidth.
+var verdanaWidths = map[rune]float64{
+	' ': 3.87, '!': 4.33, '"': 5.05, '#': 9.01, '$': 7.00, '%': 11.84, '&': 7.99, '\'': 2.95, '(': 4.99, ')': 4.99,
+	'*': 7.00, '+': 9.01, ',': 4.00, '-': 4.99, '.': 4.00, '/': 4.99, '0': 7.00, '1': 7.00, '2': 7.00, '3': 7.00,
+	'4': 7.00, '5': 7.00, '6': 7.00, '7': 7.00, '8': 7.00, '9': 7.00, ':': 4.99, ';': 4.99, '<': 9.01, '=': 9.01,
+	'>': 9.01, '?': 6.00, '@': 11.00, 'A': 7.52, 'B': 7.54, 'C': 7.68, 'D': 8.48, 'E': 6.96, 'F': 6.32, 'G': 8.53,
+	'H': 8.27, 'I': 4.61, 'J': 5.00, 'K': 7.62, 'L': 6.12, 'M': 9.27, 'N': 8.23, 'O': 8.66, 'P': 6.63, 'Q': 8.66,
+	'R': 7.65, 'S': 7.52, 'T': 6.78, 'U': 8.05, 'V': 7.52, 'W': 10.88, 'X': 7.54, 'Y': 6.77, 'Z': 7.54, '[': 4.99,
+	'\\': 4.99, ']': 4.99, '^': 9.01, '_': 7.00, '`': 7.00, 'a': 6.61, 'b': 6.85, 'c': 5.73, 'd': 6.85, 'e': 6.55,
+	'f': 3.87, 'g': 6.85, 'h': 6.96, 'i': 3.02, 'j': 3.79, 'k': 6.51, 'l': 3.02, 'm': 10.70, 'n': 6.96, 'o': 6.68,
+	'p': 6.85, 'q': 6.85, 'r': 4.69, 's': 5.73, 't': 4.33, 'u': 6.96, 'v': 6.51, 'w': 8.98, 'x': 6.51, 'y': 6.51,
+	'z': 5.78, '{': 6.98, '|': 4.99, '}': 6.98, '~': 9.01,
+}
+
+const verdanaDefaultWidth = 7.00
+
+var badgeHexColorRe = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// badgeColors maps the named colors supported by shields.io to their hex values so existing color names keep working.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+	"grey":        "#555",
+}
+
+// badgeStyle holds the geometry used when rendering one of the supported badge styles.
+type badgeStyle struct {
+	Height    int
+	Radius    int
+	Padding   int
+	FontSize  int
+	TextY     int
+	Bold      bool
+	Uppercase bool
+	Spacing   float64
+	Gradient  []badgeGradientStop
+	Shadow    bool
+}
+
+type badgeGradientStop struct {
+	Offset  string
+	Color   string
+	Opacity string
+}
+
+var badgeStyles = map[string]badgeStyle{
+	"flat": {
+		Height:   20,
+		Radius:   3,
+		Padding:  5,
+		FontSize: 11,
+		TextY:    14,
+		Shadow:   true,
+		Gradient: []badgeGradientStop{{"0", "#bbb", ".1"}, {"1", "#000", ".1"}},
+	},
+	"flat-square": {
+		Height:   20,
+		Padding:  5,
+		FontSize: 11,
+		TextY:    14,
+	},
+	"plastic": {
+		Height:   18,
+		Radius:   4,
+		Padding:  5,
+		FontSize: 11,
+		TextY:    13,
+		Shadow:   true,
+		Gradient: []badgeGradientStop{
+			{"0", "#fff", ".7"}, {".1", "#aaa", ".1"}, {".9", "#000", ".3"}, {"1", "#000", ".5"},
+		},
+	},
+	"for-the-badge": {
+		Height:    28,
+		Padding:   10,
+		FontSize:  10,
+		TextY:     18,
+		Bold:      true,
+		Uppercase: true,
+		Spacing:   1.25,
+	},
+}
+
+var badgeTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Style.Height}}">
+{{- if .Style.Gradient}}<linearGradient id="s" x2="0" y2="100%">
+{{- range .Style.Gradient}}<stop offset="{{.Offset}}" stop-color="{{.Color}}" stop-opacity="{{.Opacity}}"/>{{end -}}
+</linearGradient>{{end -}}
+<clipPath id="r"><rect width="{{.Width}}" height="{{.Style.Height}}" rx="{{.Style.Radius}}" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="{{.LabelWidth}}" height="{{.Style.Height}}" fill="{{.LabelColor}}"/>
+<rect x="{{.LabelWidth}}" width="{{.ValueWidth}}" height="{{.Style.Height}}" fill="{{.Color}}"/>
+{{- if .Style.Gradient}}<rect width="{{.Width}}" height="{{.Style.Height}}" fill="url(#s)"/>{{end -}}
+{{- if .Sparkline}}<polyline fill="none" stroke="#fff" stroke-width="1.5" points="{{.Sparkline}}"/>{{end -}}
+</g>
+<g fill="#fff" text-anchor="middle" font-family="{{.FontFamily}}" font-size="{{.Style.FontSize}}"
+{{- if .Style.Bold}} font-weight="bold"{{end}}{{if .Style.Spacing}} letter-spacing="{{.Style.Spacing}}"{{end}}>
+{{- if .Style.Shadow}}<text x="{{.LabelX}}" y="{{.ShadowY}}" fill="#010101" fill-opacity=".3">
+{{- html .Label}}</text>{{end -}}
+<text x="{{.LabelX}}" y="{{.Style.TextY}}">{{html .Label}}</text>
+{{- if .Style.Shadow}}<text x="{{.ValueX}}" y="{{.ShadowY}}" fill="#010101" fill-opacity=".3">
+{{- html .Value}}</text>{{end -}}
+<text x="{{.ValueX}}" y="{{.Style.TextY}}">{{html .Value}}</text>
+</g>
+</svg>
+`))
+
+// badge represents a two-part svg badge; a grey label on the left and a colored value on the right. When a trend is
+// provided, a sparkline of its values is drawn in the colored section to the left of the value.
+type badge struct {
+	label string
+	value string
+	color string
+	style string
+	trend []float64
+}
+
+// WriteTo renders the svg representation of the badge into the provided writer.
+func (b *badge) WriteTo(writer io.Writer) (int64, error) {
+	style, ok := badgeStyles[b.style]
+
+	if !ok {
+		return 0, fmt.Errorf("invalid-style: %s", b.style)
+	}
+
+	label, value := unescapeBadgeText(b.label), unescapeBadgeText(b.value)
+
+	if style.Uppercase {
+		label, value = strings.ToUpper(label), strings.ToUpper(value)
+	}
+
+	labelWidth := style.measure(label) + style.Padding*2
+	valueWidth := style.measure(value) + style.Padding*2
+	sparkWidth := 0
+
+	if len(b.trend) > 0 {
+		sparkWidth = badgeSparklineWidth + style.Padding
+	}
+
+	data := struct {
+		Style      badgeStyle
+		Width      int
+		LabelWidth int
+		ValueWidth int
+		LabelX     float64
+		ValueX     float64
+		ShadowY    int
+		Sparkline  string
+		Label      string
+		Value      string
+		LabelColor string
+		Color      string
+		FontFamily string
+	}{
+		Style:      style,
+		Width:      labelWidth + sparkWidth + valueWidth,
+		LabelWidth: labelWidth,
+		ValueWidth: sparkWidth + valueWidth,
+		LabelX:     float64(labelWidth) / 2,
+		ValueX:     float64(labelWidth+sparkWidth) + float64(valueWidth)/2,
+		ShadowY:    style.TextY + 1,
+		Sparkline:  sparklinePoints(b.trend, labelWidth+style.Padding, style.Height),
+		Label:      label,
+		Value:      value,
+		LabelColor: badgeLabelColor,
+		Color:      badgeColor(b.color),
+		FontFamily: badgeFontFamily,
+	}
+
+	counter := &countingWriter{writer: writer}
+	e := badgeTemplate.Execute(counter, data)
+	return counter.count, e
+}
+
+// measure returns the width (rounded up to the nearest pixel) the provided text will occupy when rendered.
+func (s badgeStyle) measure(text string) int {
+	width := float64(0)
+
+	for _, c := range text {
+		w, ok := verdanaWidths[c]
+
+		if !ok {
+			w = verdanaDefaultWidth
+		}
+
+		width += w
+	}
+
+	width = width * float64(s.FontSize) / 11
+
+	if s.Bold {
+		width = width * 1.1
+	}
+
+	width += s.Spacing * float64(len([]rune(text)))
+
+	return int(math.Ceil(width))
+}
+
+// sparklinePoints returns the svg polyline points for the values, scaled between the smallest and largest value and
+// drawn within a badgeSparklineWidth wide box that starts at the x offset.
+func sparklinePoints(values []float64, x int, height int) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	minimum, maximum := values[0], values[0]
+
+	for _, v := range values {
+		minimum, maximum = math.Min(minimum, v), math.Max(maximum, v)
+	}
+
+	step, span := float64(0), maximum-minimum
+	drawable := float64(height - badgeSparklineInset*2)
+
+	if len(values) > 1 {
+		step = float64(badgeSparklineWidth) / float64(len(values)-1)
+	}
+
+	points := make([]string, 0, len(values)+1)
+
+	for i, v := range values {
+		ratio := 0.5
+
+		if span > 0 {
+			ratio = (v - minimum) / span
+		}
+
+		y := float64(height-badgeSparklineInset) - ratio*drawable
+		points = append(points, fmt.Sprintf("%.1f,%.1f", float64(x)+step*float64(i), y))
+	}
+
+	if len(values) == 1 {
+		y := strings.SplitN(points[0], ",", 2)[1]
+		points = append(points, fmt.Sprintf("%d,%s", x+badgeSparklineWidth, y))
+	}
+
+	return strings.Join(points, " ")
+}
+
+// badgeColor returns the hex value for a named color, prefixing bare hex values with a '#'. Unknown values are grey.
+func badgeColor(color string) string {
+	if hex, ok := badgeColors[color]; ok {
+		return hex
+	}
+
+	if validBadgeColor(color) != true {
+		return badgeColors["lightgrey"]
+	}
+
+	return fmt.Sprintf("#%s", strings.TrimPrefix(color, "#"))
+}
+
+// validBadgeColor returns true if the color is either one of the named badge colors or a 3/6 digit hex value.
+func validBadgeColor(color string) bool {
+	if _, ok := badgeColors[color]; ok {
+		return true
+	}
+
+	return badgeHexColorRe.MatchString(color)
+}
+
+// unescapeBadgeText applies the shields.io path escaping rules ("--" is a dash, "__" is an underscore and a single
+// underscore is a space) so text values that were previously sent to shields.io render the same way.
+func unescapeBadgeText(text string) string {
+	replacer := strings.NewReplacer("--", "-", "__", "_", "_", " ")
+	return replacer.Replace(text)
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	n, e := w.writer.Write(data)
+	w.count += int64(n)
+	return n, e
+}
@@ -0,0 +1,98 @@
+package gendry
+
+import "bytes"
+import "testing"
+import "strings"
+import "github.com/franela/goblin"
+
+func Test_Badge(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("badge", func() {
+		var b *badge
+		var output *bytes.Buffer
+
+		g.BeforeEach(func() {
+			output = new(bytes.Buffer)
+			b = &badge{label: "generated--coverage", value: "81.00%", color: "green", style: "flat"}
+		})
+
+		g.It("returns an error if the style is not supported", func() {
+			b.style = "garbage"
+			_, e := b.WriteTo(output)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("renders each of the supported styles", func() {
+			for _, style := range []string{"flat", "flat-square", "plastic", "for-the-badge"} {
+				output.Reset()
+				b.style = style
+				amt, e := b.WriteTo(output)
+				g.Assert(e).Equal(nil)
+				g.Assert(amt).Equal(int64(output.Len()))
+				g.Assert(strings.HasPrefix(output.String(), "<svg")).Equal(true)
+			}
+		})
+
+		g.It("unescapes shield style label text", func() {
+			b.WriteTo(output)
+			g.Assert(strings.Contains(output.String(), ">generated-coverage<")).Equal(true)
+		})
+
+		g.It("uppercases text for the for-the-badge style", func() {
+			b.style = "for-the-badge"
+			b.WriteTo(output)
+			g.Assert(strings.Contains(output.String(), ">GENERATED-COVERAGE<")).Equal(true)
+		})
+
+		g.It("escapes xml characters in the label", func() {
+			b.label = "<script>"
+			b.WriteTo(output)
+			g.Assert(strings.Contains(output.String(), "<script>")).Equal(false)
+		})
+
+		g.It("translates named colors into hex values", func() {
+			b.WriteTo(output)
+			g.Assert(strings.Contains(output.String(), "#97ca00")).Equal(true)
+		})
+
+		g.It("prefixes bare hex colors", func() {
+			g.Assert(badgeColor("414141")).Equal("#414141")
+		})
+
+		g.It("falls back to grey for invalid colors", func() {
+			g.Assert(badgeColor("\"/><script>")).Equal("#9f9f9f")
+		})
+
+		g.It("measures wider text as wider", func() {
+			style := badgeStyles["flat"]
+			g.Assert(style.measure("WWW") > style.measure("iii")).Equal(true)
+		})
+	})
+
+	g.Describe("sparklinePoints", func() {
+		g.It("returns nothing without values", func() {
+			g.Assert(sparklinePoints(nil, 0, 20)).Equal("")
+		})
+
+		g.It("draws a flat line for a single value", func() {
+			g.Assert(sparklinePoints([]float64{50}, 10, 20)).Equal("10.0,10.0 50,10.0")
+		})
+
+		g.It("scales values between the smallest and largest", func() {
+			g.Assert(sparklinePoints([]float64{10, 20}, 0, 20)).Equal("0.0,16.0 40.0,4.0")
+		})
+	})
+
+	g.Describe("trend badge", func() {
+		g.It("widens the badge and renders a polyline when a trend is provided", func() {
+			plain, trending := new(bytes.Buffer), new(bytes.Buffer)
+			(&badge{label: "coverage", value: "50%", color: "green", style: "flat"}).WriteTo(plain)
+			trend := []float64{1, 2}
+			(&badge{label: "coverage", value: "50%", color: "green", style: "flat", trend: trend}).WriteTo(trending)
+			g.Assert(strings.Contains(plain.String(), "polyline")).Equal(false)
+			g.Assert(strings.Contains(trending.String(), "polyline")).Equal(true)
+			g.Assert(trending.Len() > plain.Len()).Equal(true)
+		})
+	})
+}
@@ -0,0 +1,185 @@
+package gendry
+
+import "sort"
+import "golang.org/x/tools/cover"
+import "github.com/dadleyy/gendry/gendry/models"
+
+const (
+	comparisonAdded   = "added"
+	comparisonRemoved = "removed"
+	comparisonChanged = "changed"
+)
+
+// coverageDelta represents the change in coverage of a single item (the report, a file or a package) between reports.
+type coverageDelta struct {
+	Name   string  `json:"name,omitempty"`
+	Status string  `json:"status,omitempty"`
+	Base   float64 `json:"base"`
+	Head   float64 `json:"head"`
+	Delta  float64 `json:"delta"`
+}
+
+// blockChange represents a coverage block whose covered state differs between reports.
+type blockChange struct {
+	FileName string `json:"file_name"`
+	coverageBlock
+}
+
+// reportComparison holds the differences between a base and head report.
+type reportComparison struct {
+	Base           string          `json:"base"`
+	Head           string          `json:"head"`
+	Coverage       coverageDelta   `json:"coverage"`
+	Files          []coverageDelta `json:"files"`
+	Packages       []coverageDelta `json:"packages"`
+	NewlyUncovered []blockChange   `json:"newly_uncovered"`
+	NewlyCovered   []blockChange   `json:"newly_covered"`
+}
+
+type blockPosition struct {
+	startLine int
+	startCol  int
+	endLine   int
+	endCol    int
+}
+
+// compareReports builds the comparison between two reports given each report's per-file coverage records. Only files
+// and packages that were added, removed or whose statement counts changed are included in the result.
+func compareReports(base, head *models.Report, baseFiles, headFiles []*models.CoverageFile) (*reportComparison, error) {
+	result := &reportComparison{
+		Base:           base.SystemID,
+		Head:           head.SystemID,
+		Coverage:       coverageDelta{Base: base.Coverage, Head: head.Coverage, Delta: head.Coverage - base.Coverage},
+		Files:          make([]coverageDelta, 0),
+		Packages:       make([]coverageDelta, 0),
+		NewlyUncovered: make([]blockChange, 0),
+		NewlyCovered:   make([]blockChange, 0),
+	}
+
+	baseLookup := make(map[string]*models.CoverageFile, len(baseFiles))
+
+	for _, f := range baseFiles {
+		baseLookup[f.FileName] = f
+	}
+
+	for _, f := range headFiles {
+		previous := baseLookup[f.FileName]
+		delete(baseLookup, f.FileName)
+
+		if delta, changed := fileDelta(previous, f); changed {
+			result.Files = append(result.Files, delta)
+		}
+
+		uncovered, covered, e := compareBlocks(previous, f)
+
+		if e != nil {
+			return nil, e
+		}
+
+		result.NewlyUncovered = append(result.NewlyUncovered, uncovered...)
+		result.NewlyCovered = append(result.NewlyCovered, covered...)
+	}
+
+	for _, f := range baseLookup {
+		delta, _ := fileDelta(f, nil)
+		result.Files = append(result.Files, delta)
+	}
+
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Name < result.Files[j].Name })
+
+	result.Packages = comparePackages(aggregatePackages(baseFiles), aggregatePackages(headFiles))
+
+	return result, nil
+}
+
+func fileDelta(base, head *models.CoverageFile) (coverageDelta, bool) {
+	switch {
+	case base == nil:
+		return coverageDelta{head.FileName, comparisonAdded, 0, head.Coverage, head.Coverage}, true
+	case head == nil:
+		return coverageDelta{base.FileName, comparisonRemoved, base.Coverage, 0, -base.Coverage}, true
+	}
+
+	delta := coverageDelta{
+		head.FileName, comparisonChanged, base.Coverage, head.Coverage, head.Coverage - base.Coverage,
+	}
+	changed := base.Statements != head.Statements || base.Covered != head.Covered
+
+	return delta, changed
+}
+
+func comparePackages(base, head []*packageCoverage) []coverageDelta {
+	results := make([]coverageDelta, 0)
+	lookup := make(map[string]*packageCoverage, len(base))
+
+	for _, p := range base {
+		lookup[p.Name] = p
+	}
+
+	for _, p := range head {
+		previous, ok := lookup[p.Name]
+		delete(lookup, p.Name)
+
+		if !ok {
+			results = append(results, coverageDelta{p.Name, comparisonAdded, 0, p.Coverage, p.Coverage})
+			continue
+		}
+
+		if previous.Statements == p.Statements && previous.Covered == p.Covered {
+			continue
+		}
+
+		delta := p.Coverage - previous.Coverage
+		results = append(results, coverageDelta{p.Name, comparisonChanged, previous.Coverage, p.Coverage, delta})
+	}
+
+	for _, p := range lookup {
+		results = append(results, coverageDelta{p.Name, comparisonRemoved, p.Coverage, 0, -p.Coverage})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results
+}
+
+// compareBlocks returns the blocks of the head file that are uncovered (but were covered or did not exist in the base
+// file) followed by the blocks that are covered (but were uncovered or did not exist in the base file).
+func compareBlocks(base, head *models.CoverageFile) ([]blockChange, []blockChange, error) {
+	previous := make(map[blockPosition]cover.ProfileBlock)
+
+	if base != nil {
+		blocks, e := decodeBlocks(base.Blocks)
+
+		if e != nil {
+			return nil, nil, e
+		}
+
+		for _, b := range blocks {
+			previous[blockPosition{b.StartLine, b.StartCol, b.EndLine, b.EndCol}] = b
+		}
+	}
+
+	current, e := decodeBlocks(head.Blocks)
+
+	if e != nil {
+		return nil, nil, e
+	}
+
+	uncovered, covered := make([]blockChange, 0), make([]blockChange, 0)
+
+	for _, b := range current {
+		old, existed := previous[blockPosition{b.StartLine, b.StartCol, b.EndLine, b.EndCol}]
+		change := blockChange{head.FileName, coverageBlock(b)}
+
+		if b.Count == 0 && (!existed || old.Count > 0) {
+			uncovered = append(uncovered, change)
+			continue
+		}
+
+		if b.Count > 0 && (!existed || old.Count == 0) {
+			covered = append(covered, change)
+		}
+	}
+
+	return uncovered, covered, nil
+}
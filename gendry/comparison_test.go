@@ -0,0 +1,69 @@
+package gendry
+
+import "testing"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_Comparison(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("compareReports", func() {
+		var base, head *models.Report
+		var baseFiles, headFiles []*models.CoverageFile
+
+		g.BeforeEach(func() {
+			base = &models.Report{SystemID: "base", Coverage: 50}
+			head = &models.Report{SystemID: "head", Coverage: 40}
+
+			baseFiles = []*models.CoverageFile{
+				{FileName: "a/one.go", Statements: 2, Covered: 1, Coverage: 50, Blocks: "1.1,2.1 1 1\n3.1,4.1 1 0"},
+				{FileName: "a/same.go", Statements: 1, Covered: 1, Coverage: 100, Blocks: "1.1,2.1 1 1"},
+				{FileName: "b/gone.go", Statements: 1, Covered: 1, Coverage: 100, Blocks: "1.1,2.1 1 1"},
+			}
+
+			headFiles = []*models.CoverageFile{
+				{FileName: "a/one.go", Statements: 2, Covered: 1, Coverage: 50, Blocks: "1.1,2.1 1 0\n3.1,4.1 1 1"},
+				{FileName: "a/same.go", Statements: 1, Covered: 1, Coverage: 100, Blocks: "1.1,2.1 1 1"},
+				{FileName: "c/new.go", Statements: 1, Covered: 0, Coverage: 0, Blocks: "1.1,2.1 1 0"},
+			}
+		})
+
+		g.It("calculates the overall coverage delta", func() {
+			result, e := compareReports(base, head, baseFiles, headFiles)
+			g.Assert(e).Equal(nil)
+			g.Assert(result.Coverage.Delta).Equal(float64(-10))
+		})
+
+		g.It("only includes files that were added, removed or changed", func() {
+			result, _ := compareReports(base, head, baseFiles, headFiles)
+			g.Assert(len(result.Files)).Equal(2)
+			g.Assert(result.Files[0].Name).Equal("b/gone.go")
+			g.Assert(result.Files[0].Status).Equal(comparisonRemoved)
+			g.Assert(result.Files[1].Name).Equal("c/new.go")
+			g.Assert(result.Files[1].Status).Equal(comparisonAdded)
+		})
+
+		g.It("includes package level deltas", func() {
+			result, _ := compareReports(base, head, baseFiles, headFiles)
+			g.Assert(len(result.Packages)).Equal(2)
+			g.Assert(result.Packages[0].Name).Equal("b")
+			g.Assert(result.Packages[1].Name).Equal("c")
+		})
+
+		g.It("returns blocks that are newly uncovered and newly covered", func() {
+			result, _ := compareReports(base, head, baseFiles, headFiles)
+			g.Assert(len(result.NewlyUncovered)).Equal(2)
+			g.Assert(result.NewlyUncovered[0].FileName).Equal("a/one.go")
+			g.Assert(result.NewlyUncovered[0].StartLine).Equal(1)
+			g.Assert(result.NewlyUncovered[1].FileName).Equal("c/new.go")
+			g.Assert(len(result.NewlyCovered)).Equal(1)
+			g.Assert(result.NewlyCovered[0].StartLine).Equal(3)
+		})
+
+		g.It("returns an error if the blocks are invalid", func() {
+			headFiles[0].Blocks = "garbage"
+			_, e := compareReports(base, head, baseFiles, headFiles)
+			g.Assert(e == nil).Equal(false)
+		})
+	})
+}
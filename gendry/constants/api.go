@@ -4,8 +4,35 @@ const (
 	// ProjectAuthTokenAPIHeader is the header name used to authenticate project requests.
 	ProjectAuthTokenAPIHeader = "x-project-auth"
 
+	// RequestIDHeader is the header holding the id of a request, accepted from clients and echoed in responses.
+	RequestIDHeader = "X-Request-ID"
+
+	// ServerTimingHeader is the response header reporting the time spent handling a request.
+	ServerTimingHeader = "Server-Timing"
+
 	// DisplayAPIRegex is the regular expression used to match requests to the display api
-	DisplayAPIRegex = "^/reports/(?P<project>[\\w\\/]+)/(?P<tag>[A-z0-9]+)\\.(?P<format>html|svg)"
+	DisplayAPIRegex = "^/reports/(?P<project>[\\w\\/]+)/(?P<tag>[A-z0-9]+)\\.(?P<format>html|svg|trend\\.svg)"
+
+	// ReportAPIRegex is the regular expression used to match requests to the report api.
+	ReportAPIRegex = "^/reports"
+
+	// ProjectAPIRegex is the regular expression used to match requests to the project api.
+	ProjectAPIRegex = "^/projects"
+
+	// ReportCoverageAPIRegex is the regular expression used to match requests for the coverage breakdown of a report.
+	ReportCoverageAPIRegex = "^/reports/(?P<report_id>[^/]+)/(?P<resource>files|packages|gate)$"
+
+	// ReportArtifactAPIRegex is the regular expression used to match requests for the stored html file of a report.
+	ReportArtifactAPIRegex = "^/reports/(?P<report_id>[^/]+)/artifact$"
+
+	// ReportCompareAPIRegex is the regular expression used to match requests comparing two reports.
+	ReportCompareAPIRegex = "^/reports/(?P<action>compare)$"
+
+	// ProjectTrendAPIRegex is the regular expression used to match requests for a project's coverage over time.
+	ProjectTrendAPIRegex = "^/projects/(?P<project_id>[^/]+)/trend$"
+
+	// MetricsAPIRegex is the regular expression used to match requests for the prometheus metrics.
+	MetricsAPIRegex = "^/metrics$"
 
 	// ProjectIDParamName is used as the key wherever a project id is expected.
 	ProjectIDParamName = "project_id"
@@ -19,12 +46,97 @@ const (
 	// ReportIDParamName is used as the key wherever a report id is expected.
 	ReportIDParamName = "report_id"
 
+	// ReportTagParamName is used as the key by clients to restrict report listings to a single tag.
+	ReportTagParamName = "tag"
+
+	// ReportCommitParamName is used as the key by clients to restrict report listings to a single commit.
+	ReportCommitParamName = "commit"
+
+	// ReportBranchParamName is used as the key by clients to restrict report listings to a single branch.
+	ReportBranchParamName = "branch"
+
+	// ReportHistoryParamName is used as the key by clients to list every report of a tag rather than only the latest.
+	ReportHistoryParamName = "history"
+
 	// ReportProjectIDBodyParam is the body param key that will be used as the project id in the report upload request.
 	ReportProjectIDBodyParam = "project_id"
 
+	// ReportCommitBodyParam is the optional body param key holding the commit sha a report was generated from.
+	ReportCommitBodyParam = "commit"
+
+	// ReportBranchBodyParam is the optional body param key holding the branch a report was generated from.
+	ReportBranchBodyParam = "branch"
+
+	// ReportPullRequestBodyParam is the optional body param key holding the pull request number of a report.
+	ReportPullRequestBodyParam = "pull_request"
+
+	// ReportBuildURLBodyParam is the optional body param key holding the url of the ci build that uploaded a report.
+	ReportBuildURLBodyParam = "build_url"
+
+	// ReportAuthorBodyParam is the optional body param key holding the author of the commit a report was built from.
+	ReportAuthorBodyParam = "author"
+
+	// ReportTimestampBodyParam is the optional body param key holding the time (RFC3339 or unix) of the ci build.
+	ReportTimestampBodyParam = "timestamp"
+
+	// ReportGateBodyParam is the optional body param key that, when "true", runs the new report through the gate.
+	ReportGateBodyParam = "gate"
+
+	// ReportChangedFilesBodyParam is the optional body param key holding the paths changed in the diff of a report,
+	// checked against the changed file minimum of the gate.
+	ReportChangedFilesBodyParam = "changed_files"
+
 	// ReportFileBodyParam is the body param key that will be used to load files for a given report.
 	ReportFileBodyParam = "files"
 
+	// CoverageFileParamName is used as the key by clients to restrict coverage files to a single file name.
+	CoverageFileParamName = "file"
+
+	// CoverageSortParamName is used as the key by clients to specify the ordering of coverage breakdowns.
+	CoverageSortParamName = "sort"
+
+	// CoverageBlocksParamName is used as the key by clients to request the coverage blocks of each file.
+	CoverageBlocksParamName = "blocks"
+
+	// CompareBaseParamName is used as the key by clients to specify the report (id or tag) compared against.
+	CompareBaseParamName = "base"
+
+	// CompareHeadParamName is used as the key by clients to specify the report (id or tag) being compared.
+	CompareHeadParamName = "head"
+
+	// TrendTagParamName is used as the key by clients to restrict a coverage trend to reports of a single tag.
+	TrendTagParamName = "tag"
+
+	// TrendSinceParamName is used as the key by clients to specify the start (date or RFC3339) of a coverage trend.
+	TrendSinceParamName = "since"
+
+	// TrendUntilParamName is used as the key by clients to specify the end (date or RFC3339) of a coverage trend.
+	TrendUntilParamName = "until"
+
+	// TrendBucketParamName is used as the key by clients to specify the trend grouping (report, day, week, month).
+	TrendBucketParamName = "bucket"
+
+	// MaxTrendReports is the largest amount of reports (the most recent of the window) a coverage trend is built from.
+	MaxTrendReports = 1000
+
+	// SparklinePointsParamName is used as the key by clients to specify how many reports a trend badge will show.
+	SparklinePointsParamName = "points"
+
+	// GateBaseParamName is used as the key by clients to override the base tag a report's quality gate compares to.
+	GateBaseParamName = "base"
+
+	// GateChangedFilesParamName is used as the key by clients to list the paths changed in the diff being gated.
+	GateChangedFilesParamName = "changed_files"
+
+	// ReportRawParamName is used as the key by clients to download a report's html file exactly as it was uploaded.
+	ReportRawParamName = "raw"
+
+	// ArtifactVerifyParamName is used by clients to have a report's stored file checked against its checksum.
+	ArtifactVerifyParamName = "verify"
+
 	// ShieldTextQueryParam is used as a query param key that, if provided, will determine which text to display.
 	ShieldTextQueryParam = "text"
+
+	// ShieldStyleQueryParam is used as a query param key that, if provided, will determine the badge style.
+	ShieldStyleQueryParam = "style"
 )
@@ -12,4 +12,26 @@ const (
 
 	// AWSAccessKeyIDEnvVariable holds the id of the key being used for communication.
 	AWSAccessKeyIDEnvVariable = "AWS_ACCESS_ID"
+
+	// AWSRegionEnvVariable defines the key under which the aws region is stored.
+	AWSRegionEnvVariable = "AWS_REGION"
+
+	// AWSEndpointEnvVariable defines the key under which a custom (s3-compatible) endpoint url is stored.
+	AWSEndpointEnvVariable = "AWS_ENDPOINT"
+
+	// AWSForcePathStyleEnvVariable defines the key under which the path-style addressing toggle is stored.
+	AWSForcePathStyleEnvVariable = "AWS_S3_FORCE_PATH_STYLE"
+
+	// AWSSkipVerifyEnvVariable defines the key under which the tls certificate verification toggle is stored.
+	AWSSkipVerifyEnvVariable = "AWS_INSECURE_SKIP_VERIFY"
+
+	// AWSCABundleEnvVariable defines the key under which the path of a custom certificate authority bundle is stored.
+	AWSCABundleEnvVariable = "AWS_CA_BUNDLE"
+
+	// AWSKeyPrefixEnvVariable defines the key under which the prefix applied to every object key is stored.
+	AWSKeyPrefixEnvVariable = "AWS_KEY_PREFIX"
+
+	// AWSPresignExpiryEnvVariable defines the key under which the lifetime of presigned report urls is stored; report
+	// html is proxied rather than redirected to a presigned url when it is empty.
+	AWSPresignExpiryEnvVariable = "AWS_PRESIGN_EXPIRY"
 )
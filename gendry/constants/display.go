@@ -4,12 +4,25 @@ const (
 	// GoodCoverageAmount is the float value that will determine when the display api renders green badges.
 	GoodCoverageAmount = 80.00
 
-	// DefaultShieldStyle is the style used in shield.io requests if the user has not provided one.
+	// GoodCoverageColor is the badge color used once coverage passes GoodCoverageAmount for projects w/o thresholds.
+	GoodCoverageColor = "green"
+
+	// DefaultCoverageColor is the badge color used when no coverage threshold has been satisfied.
+	DefaultCoverageColor = "414141"
+
+	// ReportHeaderTemplate is the html element inserted at the top of served reports, given its color and value.
+	ReportHeaderTemplate = `<div style="padding:8px 12px;font:bold 14px sans-serif;color:#fff;background:%s">` +
+		"coverage %s</div>"
+
+	// DefaultShieldStyle is the style used when rendering badges if the user has not provided one.
 	DefaultShieldStyle = "flat-square"
 
-	// ShieldConfigTemplate defines the string formatting used for shield text.
-	ShieldConfigTemplate = "%s-%.2f%%-%s"
+	// DefaultSparklinePoints is the amount of reports rendered in trend badges if the user has not provided one.
+	DefaultSparklinePoints = 10
+
+	// MaxSparklinePoints is the largest amount of reports that will be rendered in a trend badge.
+	MaxSparklinePoints = 50
 
-	// ShieldURLTemplate defines where the shields api lives.
-	ShieldURLTemplate = "https://img.shields.io/badge/%s.svg"
+	// ShieldValueTemplate defines the string formatting used for the coverage value on the right side of a badge.
+	ShieldValueTemplate = "%.2f%%"
 )
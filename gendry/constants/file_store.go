@@ -0,0 +1,18 @@
+package constants
+
+const (
+	// FileStoreDriverEnvVariable defines the key under which the file store driver name (s3, local, memory) is stored.
+	FileStoreDriverEnvVariable = "FILE_STORE_DRIVER"
+
+	// FileStoreDirectoryEnvVariable defines the key under which the local file store's root directory is stored.
+	FileStoreDirectoryEnvVariable = "FILE_STORE_DIRECTORY"
+
+	// FileStoreCompressionEnvVariable defines the key under which the compression (gzip or none) of files is stored.
+	FileStoreCompressionEnvVariable = "FILE_STORE_COMPRESSION"
+
+	// MemoryStoreMaxBytesEnvVariable defines the key under which the memory file store's total size limit is stored.
+	MemoryStoreMaxBytesEnvVariable = "MEMORY_STORE_MAX_BYTES"
+
+	// MemoryStoreMaxFilesEnvVariable defines the key under which the memory file store's file count limit is stored.
+	MemoryStoreMaxFilesEnvVariable = "MEMORY_STORE_MAX_FILES"
+)
@@ -0,0 +1,21 @@
+package constants
+
+const (
+	// TLSCertFileEnvVariable defines the key under which the path of the server's tls certificate is stored.
+	TLSCertFileEnvVariable = "TLS_CERT_FILE"
+
+	// TLSKeyFileEnvVariable defines the key under which the path of the server's tls private key is stored.
+	TLSKeyFileEnvVariable = "TLS_KEY_FILE"
+
+	// ReadTimeoutEnvVariable defines the key under which the maximum duration of reading a request is stored.
+	ReadTimeoutEnvVariable = "READ_TIMEOUT"
+
+	// WriteTimeoutEnvVariable defines the key under which the maximum duration of writing a response is stored.
+	WriteTimeoutEnvVariable = "WRITE_TIMEOUT"
+
+	// IdleTimeoutEnvVariable defines the key under which the lifetime of idle keep-alive connections is stored.
+	IdleTimeoutEnvVariable = "IDLE_TIMEOUT"
+
+	// ShutdownTimeoutEnvVariable defines the key under which the time in-flight requests may drain is stored.
+	ShutdownTimeoutEnvVariable = "SHUTDOWN_TIMEOUT"
+)
@@ -1,6 +0,0 @@
-package constants
-
-const (
-	// MaxHTMLReportFileSize defines how large the byte slice used to read report data into will be.
-	MaxHTMLReportFileSize = 2048
-)
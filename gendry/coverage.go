@@ -2,11 +2,14 @@ package gendry
 
 import "io"
 import "fmt"
+import "path"
+import "sort"
 import "bufio"
 import "regexp"
 import "strings"
 import "strconv"
 import "golang.org/x/tools/cover"
+import "github.com/dadleyy/gendry/gendry/models"
 
 const (
 	modeIdentifier = "mode: "
@@ -14,6 +17,8 @@ const (
 
 var lineRe = regexp.MustCompile(`^(.+):([0-9]+).([0-9]+),([0-9]+).([0-9]+) ([0-9]+) ([0-9]+)$`)
 
+var blockRe = regexp.MustCompile(`^([0-9]+).([0-9]+),([0-9]+).([0-9]+) ([0-9]+) ([0-9]+)$`)
+
 type reportProfile struct {
 	coverage float64
 	files    map[string]*cover.Profile
@@ -99,6 +104,132 @@ func parseCoverProfile(r io.Reader) (*reportProfile, error) {
 	}, nil
 }
 
+// coverageFiles returns the per-file coverage records of the profile, associated with the given report id.
+func (p *reportProfile) coverageFiles(reportID string) []models.CoverageFile {
+	results := make([]models.CoverageFile, 0, len(p.files))
+
+	for name, profile := range p.files {
+		statements, covered := profileStatements(profile.Blocks)
+
+		results = append(results, models.CoverageFile{
+			ReportID:   reportID,
+			FileName:   name,
+			Statements: statements,
+			Covered:    covered,
+			Coverage:   percentCovered(statements, covered),
+			Blocks:     encodeBlocks(profile.Blocks),
+		})
+	}
+
+	return results
+}
+
+// packageCoverage holds the statement totals of every file within a single go package (directory).
+type packageCoverage struct {
+	Name       string  `json:"package"`
+	Files      int     `json:"files"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Coverage   float64 `json:"coverage"`
+}
+
+// aggregatePackages rolls the per-file coverage records up by package, returning the packages sorted by name.
+func aggregatePackages(files []*models.CoverageFile) []*packageCoverage {
+	packages := make(map[string]*packageCoverage)
+	results := make([]*packageCoverage, 0)
+
+	for _, f := range files {
+		name := path.Dir(f.FileName)
+		summary, ok := packages[name]
+
+		if !ok {
+			summary = &packageCoverage{Name: name}
+			packages[name] = summary
+			results = append(results, summary)
+		}
+
+		summary.Files++
+		summary.Statements += f.Statements
+		summary.Covered += f.Covered
+	}
+
+	for _, summary := range results {
+		summary.Coverage = percentCovered(summary.Statements, summary.Covered)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results
+}
+
+// profileStatements returns the total and covered amount of statements in the list of blocks.
+func profileStatements(blocks []cover.ProfileBlock) (int, int) {
+	total, covered := 0, 0
+
+	for _, block := range blocks {
+		total += block.NumStmt
+
+		if block.Count > 0 {
+			covered += block.NumStmt
+		}
+	}
+
+	return total, covered
+}
+
+func percentCovered(total int, covered int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(covered) / float64(total) * 100
+}
+
+// encodeBlocks serializes blocks using the cover profile line format (sans file name), one block per line.
+func encodeBlocks(blocks []cover.ProfileBlock) string {
+	lines := make([]string, len(blocks))
+
+	for i, b := range blocks {
+		lines[i] = fmt.Sprintf("%d.%d,%d.%d %d %d", b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// decodeBlocks parses the output of encodeBlocks back into a list of profile blocks.
+func decodeBlocks(encoded string) ([]cover.ProfileBlock, error) {
+	blocks := make([]cover.ProfileBlock, 0)
+
+	if encoded == "" {
+		return blocks, nil
+	}
+
+	for _, line := range strings.Split(encoded, "\n") {
+		match := blockRe.FindStringSubmatch(line)
+
+		if match == nil {
+			return nil, fmt.Errorf("invalid-block: [%s]", line)
+		}
+
+		intVals, e := atois(match[1:]...)
+
+		if e != nil {
+			return nil, e
+		}
+
+		blocks = append(blocks, cover.ProfileBlock{
+			StartLine: intVals[0],
+			StartCol:  intVals[1],
+			EndLine:   intVals[2],
+			EndCol:    intVals[3],
+			NumStmt:   intVals[4],
+			Count:     intVals[5],
+		})
+	}
+
+	return blocks, nil
+}
+
 func atois(strings ...string) ([]int, error) {
 	results := []int{}
 
@@ -3,6 +3,7 @@ package gendry
 import "testing"
 import "strings"
 import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
 
 func Test_Coverage(t *testing.T) {
 	g := goblin.Goblin(t)
@@ -41,4 +42,72 @@ func Test_Coverage(t *testing.T) {
 			g.Assert(r.coverage).Equal(100)
 		})
 	})
+
+	g.Describe("coverageFiles", func() {
+		var profile *reportProfile
+
+		g.BeforeEach(func() {
+			profile, _ = parseCoverProfile(strings.NewReader(`mode: atomic
+github.com/dadleyy/gendry/gendry/coverage.go:11.81,12.52 3 3
+github.com/dadleyy/gendry/gendry/coverage.go:14.2,16.10 1 0
+github.com/dadleyy/gendry/gendry/routing.go:20.1,21.3 4 0`))
+		})
+
+		g.It("returns a record for each file in the profile", func() {
+			files := profile.coverageFiles("report-id")
+			g.Assert(len(files)).Equal(2)
+		})
+
+		g.It("calculates statement counts and coverage for each file", func() {
+			for _, f := range profile.coverageFiles("report-id") {
+				g.Assert(f.ReportID).Equal("report-id")
+
+				if f.FileName == "github.com/dadleyy/gendry/gendry/coverage.go" {
+					g.Assert(f.Statements).Equal(4)
+					g.Assert(f.Covered).Equal(3)
+					g.Assert(f.Coverage).Equal(float64(75))
+					continue
+				}
+
+				g.Assert(f.Statements).Equal(4)
+				g.Assert(f.Covered).Equal(0)
+				g.Assert(f.Coverage).Equal(float64(0))
+			}
+		})
+
+		g.It("encodes blocks in a format that can be decoded", func() {
+			blocks := profile.files["github.com/dadleyy/gendry/gendry/coverage.go"].Blocks
+			decoded, e := decodeBlocks(encodeBlocks(blocks))
+			g.Assert(e).Equal(nil)
+			g.Assert(decoded).Equal(blocks)
+		})
+
+		g.It("returns an error when decoding invalid blocks", func() {
+			_, e := decodeBlocks("1.2,3.4 5")
+			g.Assert(e == nil).Equal(false)
+		})
+	})
+
+	g.Describe("aggregatePackages", func() {
+		g.It("rolls file coverage up by directory, sorted by package name", func() {
+			packages := aggregatePackages([]*models.CoverageFile{
+				{FileName: "github.com/a/b/routing.go", Statements: 10, Covered: 5},
+				{FileName: "github.com/a/a/coverage.go", Statements: 4, Covered: 4},
+				{FileName: "github.com/a/b/runtime.go", Statements: 10, Covered: 0},
+			})
+
+			g.Assert(len(packages)).Equal(2)
+			g.Assert(packages[0].Name).Equal("github.com/a/a")
+			g.Assert(packages[0].Coverage).Equal(float64(100))
+			g.Assert(packages[1].Name).Equal("github.com/a/b")
+			g.Assert(packages[1].Files).Equal(2)
+			g.Assert(packages[1].Statements).Equal(20)
+			g.Assert(packages[1].Covered).Equal(5)
+			g.Assert(packages[1].Coverage).Equal(float64(25))
+		})
+
+		g.It("returns an empty list without files", func() {
+			g.Assert(len(aggregatePackages(nil))).Equal(0)
+		})
+	})
 }
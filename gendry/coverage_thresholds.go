@@ -0,0 +1,97 @@
+package gendry
+
+import "fmt"
+import "sort"
+import "strings"
+import "strconv"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// coverageThreshold is a single color band; any coverage value at or above the minimum uses the band's color.
+type coverageThreshold struct {
+	Minimum float64 `json:"minimum"`
+	Color   string  `json:"color"`
+}
+
+// coverageThresholds is a list of color bands, persisted on projects as a comma separated "minimum:color" string.
+type coverageThresholds []coverageThreshold
+
+// parseCoverageThresholds decodes the persisted representation of a project's threshold list.
+func parseCoverageThresholds(encoded string) (coverageThresholds, error) {
+	result := make(coverageThresholds, 0)
+
+	if strings.TrimSpace(encoded) == "" {
+		return result, nil
+	}
+
+	for _, band := range strings.Split(encoded, ",") {
+		parts := strings.SplitN(strings.TrimSpace(band), ":", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid-threshold: %s", band)
+		}
+
+		minimum, e := strconv.ParseFloat(parts[0], 64)
+
+		if e != nil {
+			return nil, fmt.Errorf("invalid-threshold: %s", band)
+		}
+
+		result = append(result, coverageThreshold{Minimum: minimum, Color: parts[1]})
+	}
+
+	return result, result.validate()
+}
+
+// validate ensures every band has a renderable color and a minimum within the 0-100 percent range.
+func (t coverageThresholds) validate() error {
+	for _, band := range t {
+		if band.Minimum < 0 || band.Minimum > 100 {
+			return fmt.Errorf("invalid-threshold-minimum: %f", band.Minimum)
+		}
+
+		if validBadgeColor(band.Color) != true {
+			return fmt.Errorf("invalid-threshold-color: %s", band.Color)
+		}
+	}
+
+	return nil
+}
+
+// color returns the color of the band with the highest minimum that the coverage value satisfies. When the list is
+// empty the legacy behavior is used; grey until constants.GoodCoverageAmount has been passed.
+func (t coverageThresholds) color(coverage float64) string {
+	if len(t) == 0 {
+		if coverage > constants.GoodCoverageAmount {
+			return constants.GoodCoverageColor
+		}
+
+		return constants.DefaultCoverageColor
+	}
+
+	sorted := make(coverageThresholds, len(t))
+	copy(sorted, t)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Minimum < sorted[j].Minimum })
+
+	color := constants.DefaultCoverageColor
+
+	for _, band := range sorted {
+		if coverage < band.Minimum {
+			break
+		}
+
+		color = band.Color
+	}
+
+	return color
+}
+
+// String returns the persisted representation of the threshold list.
+func (t coverageThresholds) String() string {
+	bands := make([]string, len(t))
+
+	for i, band := range t {
+		bands[i] = fmt.Sprintf("%s:%s", strconv.FormatFloat(band.Minimum, 'f', -1, 64), band.Color)
+	}
+
+	return strings.Join(bands, ",")
+}
@@ -0,0 +1,72 @@
+package gendry
+
+import "testing"
+import "encoding/json"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+func Test_CoverageThresholds(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("coverageThresholds", func() {
+		g.It("parses an empty string into an empty list", func() {
+			bands, e := parseCoverageThresholds("")
+			g.Assert(e).Equal(nil)
+			g.Assert(len(bands)).Equal(0)
+		})
+
+		g.It("returns an error for poorly formatted bands", func() {
+			_, e := parseCoverageThresholds("50-red")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for non-numeric minimums", func() {
+			_, e := parseCoverageThresholds("abc:red")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for unknown colors", func() {
+			_, e := parseCoverageThresholds("50:notacolor")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for minimums outside of 0-100", func() {
+			_, e := parseCoverageThresholds("101:red")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("round trips through the persisted string format", func() {
+			bands, e := parseCoverageThresholds("0:red,50:orange,70:yellow,80:green,95:brightgreen")
+			g.Assert(e).Equal(nil)
+			g.Assert(bands.String()).Equal("0:red,50:orange,70:yellow,80:green,95:brightgreen")
+		})
+
+		g.It("picks the band with the highest satisfied minimum, regardless of order", func() {
+			bands, _ := parseCoverageThresholds("95:brightgreen,0:red,80:green,50:orange,70:yellow")
+			g.Assert(bands.color(10)).Equal("red")
+			g.Assert(bands.color(50)).Equal("orange")
+			g.Assert(bands.color(79.9)).Equal("yellow")
+			g.Assert(bands.color(80)).Equal("green")
+			g.Assert(bands.color(99)).Equal("brightgreen")
+		})
+
+		g.It("uses the default color when no band is satisfied", func() {
+			bands, _ := parseCoverageThresholds("50:green")
+			g.Assert(bands.color(10)).Equal(constants.DefaultCoverageColor)
+		})
+
+		g.It("falls back to the legacy good coverage behavior when empty", func() {
+			bands := coverageThresholds{}
+			g.Assert(bands.color(constants.GoodCoverageAmount - 1)).Equal(constants.DefaultCoverageColor)
+			g.Assert(bands.color(constants.GoodCoverageAmount + 1)).Equal(constants.GoodCoverageColor)
+		})
+
+		g.It("decodes from json", func() {
+			var bands coverageThresholds
+			e := json.Unmarshal([]byte(`[{"minimum": 50, "color": "red"}]`), &bands)
+			g.Assert(e).Equal(nil)
+			g.Assert(bands.validate()).Equal(nil)
+			g.Assert(bands.String()).Equal("50:red")
+		})
+	})
+}
@@ -4,28 +4,40 @@ import "io"
 import "fmt"
 import "log"
 import "path"
+import "time"
+import "bytes"
+import "strconv"
 import "net/url"
 import "net/http"
+import "crypto/sha256"
 
 import "github.com/dadleyy/gendry/gendry/models"
 import "github.com/dadleyy/gendry/gendry/constants"
 
-// NewDisplayAPI returns a new APIEndpoint capable of returning badge data from shields.io.
-func NewDisplayAPI(reports models.ReportStore, projects models.ProjectStore, files FileStore) APIEndpoint {
+// NewDisplayAPI returns a new APIEndpoint capable of rendering svg badges and html reports.
+func NewDisplayAPI(
+	reports models.ReportStore, projects models.ProjectStore, files FileStore, metrics *Metrics,
+) APIEndpoint {
 	api := &displayAPI{
-		projects: projects,
-		reports:  reports,
-		files:    files,
+		reportAuthority: reportAuthority{projects: projects, reports: reports},
+		files:           files,
+		badgeRequests: metrics.Counter(
+			"gendry_badge_requests_total", "Badges and html reports rendered, by format.", "format",
+		),
+		badgeCache: metrics.Counter(
+			"gendry_badge_cache_total", "Badge requests answered from the client's cache (hit) or rendered (miss).",
+			"result",
+		),
 	}
 	return api
 }
 
-// displayAPI is responsible for writing the svg badge result from shields.io given a report name.
+// displayAPI is responsible for writing the svg badge result (or the html report) given a report name.
 type displayAPI struct {
-	notImplementedRoute
-	projects models.ProjectStore
-	reports  models.ReportStore
-	files    FileStore
+	reportAuthority
+	files         FileStore
+	badgeRequests *Counter
+	badgeCache    *Counter
 }
 
 func (a *displayAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
@@ -40,78 +52,194 @@ func (a *displayAPI) Get(writer http.ResponseWriter, request *http.Request, para
 		return
 	}
 
-	reports, e := a.reports.FindReports(&models.ReportBlueprint{
-		Tag:       []string{params.Get("tag")},
-		ProjectID: []string{matches[0].SystemID},
-	})
+	report, e := a.latestReport(matches[0].SystemID, params.Get("tag"))
 
-	if e != nil || len(reports) != 1 {
-		log.Printf("uanble to find report %s (error: %v) (count: %d)", params.Get("tag"), e, len(reports))
+	if e != nil {
+		log.Printf("uanble to find report %s (error: %v)", params.Get("tag"), e)
 		writer.WriteHeader(404)
 		fmt.Fprintf(writer, "not-found")
 		return
 	}
 
-	if params.Get("format") == "html" {
-		a.renderHTML(writer, reports[0])
-		return
+	a.badgeRequests.Inc(params.Get("format"))
+
+	switch params.Get("format") {
+	case "html":
+		a.renderReport(writer, request, matches[0], report)
+	case "trend.svg":
+		a.renderTrendBadge(writer, request, a.badge(request, matches[0], report), report)
+	default:
+		a.renderBadge(writer, request, a.badge(request, matches[0], report))
 	}
+}
 
-	color, text := "414141", "generated--coverage"
+// renderTrendBadge renders the badge with a sparkline of the coverage of the most recent reports sharing the tag.
+func (a *displayAPI) renderTrendBadge(
+	writer http.ResponseWriter, request *http.Request, shield *badge, r *models.Report,
+) {
+	limit := constants.DefaultSparklinePoints
 
-	if t := request.URL.Query().Get(constants.ShieldTextQueryParam); t != "" {
-		text = t
+	if points, e := strconv.Atoi(request.URL.Query().Get(constants.SparklinePointsParamName)); e == nil && points > 0 {
+		limit = points
 	}
 
-	if reports[0].Coverage > constants.GoodCoverageAmount {
-		color = "green"
+	if limit > constants.MaxSparklinePoints {
+		limit = constants.MaxSparklinePoints
 	}
 
-	escapedConfig := url.PathEscape(fmt.Sprintf(constants.ShieldConfigTemplate, text, reports[0].Coverage, color))
-	shieldURL, e := url.Parse(fmt.Sprintf(constants.ShieldURLTemplate, escapedConfig))
+	history, e := a.reports.FindReports(&models.ReportBlueprint{
+		Tag:            []string{r.Tag},
+		ProjectID:      []string{r.ProjectID},
+		OrderBy:        "id",
+		OrderDirection: "DESC",
+		Limit:          limit,
+	})
 
 	if e != nil {
-		log.Printf("unable to build badge url: %s", e.Error())
+		log.Printf("unable to load report history for %s (error: %v)", r.Tag, e)
 		writer.WriteHeader(500)
 		return
 	}
 
-	shieldQueryParams := url.Values{
-		"style": []string{constants.DefaultShieldStyle},
+	shield.trend = make([]float64, len(history))
+
+	for i, report := range history {
+		shield.trend[len(history)-1-i] = report.Coverage
+	}
+
+	a.renderBadge(writer, request, shield)
+}
+
+// renderBadge writes the badge tagged with the checksum of its content, answering clients that already hold the same
+// badge with a 304 (a cache hit) instead of the content.
+func (a *displayAPI) renderBadge(writer http.ResponseWriter, request *http.Request, shield *badge) {
+	output := new(bytes.Buffer)
+
+	if _, e := shield.WriteTo(output); e != nil {
+		log.Printf("unable to render badge: %s", e.Error())
+		writer.WriteHeader(500)
+		return
+	}
+
+	cacheValue := fmt.Sprintf("max-age=%d", 10)
+	tag := fmt.Sprintf("\"%x\"", sha256.Sum256(output.Bytes()))
+
+	writer.Header().Set("Cache-Control", cacheValue)
+	writer.Header().Set("ETag", tag)
+
+	if request.Header.Get("If-None-Match") == tag {
+		a.badgeCache.Inc("hit")
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	a.badgeCache.Inc("miss")
+	writer.Header().Set("Content-Type", "image/svg+xml")
+	writer.WriteHeader(200)
+	io.Copy(writer, output)
+}
+
+func (a *displayAPI) badge(request *http.Request, project *models.Project, report *models.Report) *badge {
+	text, style := "generated--coverage", constants.DefaultShieldStyle
+
+	if t := request.URL.Query().Get(constants.ShieldTextQueryParam); t != "" {
+		text = t
 	}
 
-	shieldURL.RawQuery = shieldQueryParams.Encode()
+	if s := request.URL.Query().Get(constants.ShieldStyleQueryParam); s != "" {
+		if _, valid := badgeStyles[s]; valid {
+			style = s
+		}
+	}
 
-	client := &http.Client{}
-	shieldRequest, e := http.NewRequest("GET", shieldURL.String(), nil)
+	return &badge{
+		label: text,
+		value: fmt.Sprintf(constants.ShieldValueTemplate, report.Coverage),
+		color: a.thresholds(project).color(report.Coverage),
+		style: style,
+	}
+}
+
+// thresholds returns the color bands configured for the project, falling back to the defaults if they are invalid.
+func (a *displayAPI) thresholds(project *models.Project) coverageThresholds {
+	thresholds, e := parseCoverageThresholds(project.CoverageThresholds)
 
 	if e != nil {
-		log.Printf("unable to load shield: %s", e.Error())
-		writer.WriteHeader(404)
+		log.Printf("invalid thresholds for project %s: %v", project.SystemID, e)
+		return coverageThresholds{}
+	}
+
+	return thresholds
+}
+
+// renderReport renders the report's html file with a header colored by the project's coverage band, unless the client
+// asked for the file as it was uploaded. The band is not stored with the file, so it follows the project's current
+// thresholds.
+func (a *displayAPI) renderReport(
+	writer http.ResponseWriter, request *http.Request, project *models.Project, report *models.Report,
+) {
+	if request.URL.Query().Get(constants.ReportRawParamName) == "true" {
+		a.renderHTML(writer, request, report)
 		return
 	}
 
-	shieldResponse, e := client.Do(shieldRequest)
+	file, e := a.files.FindFile(path.Join(reportFileDirectory, report.HTMLFileID))
 
 	if e != nil {
-		log.Printf("unable to request shield data: %s", e.Error())
-		writer.WriteHeader(502)
+		log.Printf("unable to find file for report: %v", e)
+		writer.WriteHeader(404)
 		return
 	}
 
-	defer shieldResponse.Body.Close()
+	defer file.Close()
 
-	cacheValue := fmt.Sprintf("max-age=%d", 10)
+	html, e := withReportHeader(file, reportHeader(report.Coverage, a.thresholds(project)))
 
-	writer.Header().Set("Cache-Control", cacheValue)
-	writer.Header().Set("Content-Type", "image/svg+xml")
+	if e != nil {
+		log.Printf("unable to read file for report: %v", e)
+		writer.WriteHeader(500)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html")
+	writer.Header().Set("Cache-Control", "no-store")
 	writer.WriteHeader(200)
-	io.Copy(writer, shieldResponse.Body)
+
+	if amt, e := io.Copy(writer, html); e != nil && amt == 0 {
+		log.Printf("strange copy on report html, bytes sent: %d (error: %v)", amt, e)
+	}
 }
 
-func (a *displayAPI) renderHTML(writer http.ResponseWriter, report *models.Report) {
+// renderHTML streams the report's html file as it was uploaded, supporting range requests when the file is seekable or
+// the file store can read byte ranges itself, and serving compressed files without decompressing them when the client
+// allows it.
+func (a *displayAPI) renderHTML(writer http.ResponseWriter, request *http.Request, report *models.Report) {
 	log.Printf("loading report html for %s", report.SystemID)
-	reader, e := a.files.FindFile(path.Join("reports", report.HTMLFileID))
+	name := path.Join(reportFileDirectory, report.HTMLFileID)
+
+	if a.redirectHTML(writer, request, report) {
+		return
+	}
+
+	ranged, rangeable := a.files.(RangeFileStore)
+
+	if !rangeable {
+		ranged = fullFileStore{a.files}
+	}
+
+	file, e := ranged.FindFileRange(name, request.Header.Get("Range"))
+
+	// Ranges of encoded files are ranges of the stored content; clients that can not accept the encoding are sent the
+	// whole file instead.
+	if e == nil && file.ContentRange != "" && file.Encoding != "" && acceptsEncoding(request, file.Encoding) != true {
+		file.Close()
+		file, e = ranged.FindFileRange(name, "")
+	}
+
+	if e == ErrInvalidRange {
+		writer.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
 
 	if e != nil {
 		log.Printf("unable to find file for report: %v", e)
@@ -119,11 +247,53 @@ func (a *displayAPI) renderHTML(writer http.ResponseWriter, report *models.Repor
 		return
 	}
 
+	// Encoded files are served as stored to clients accepting the encoding, and decoded for other clients; the decoded
+	// content is sent whole, ignoring any requested range.
+	switch {
+	case file.Encoding == "":
+	case acceptsEncoding(request, file.Encoding):
+		writer.Header().Set("Vary", "Accept-Encoding")
+		writer.Header().Set("Content-Encoding", file.Encoding)
+	default:
+		writer.Header().Set("Vary", "Accept-Encoding")
+		reader, e := decodeFile(file.ReadCloser, file.Encoding)
+
+		if e != nil {
+			log.Printf("unable to decode file for report: %v", e)
+			writer.WriteHeader(500)
+			return
+		}
+
+		file, rangeable = &FileRange{ReadCloser: reader}, false
+		writer.Header().Set("Accept-Ranges", "none")
+	}
+
+	defer file.Close()
+
 	writer.Header().Set("Content-Type", "text/html")
-	writer.WriteHeader(200)
 
-	defer reader.Close()
-	amt, e := io.Copy(writer, reader)
+	if seeker, ok := file.ReadCloser.(io.ReadSeeker); ok {
+		http.ServeContent(writer, request, "", time.Time{}, seeker)
+		return
+	}
+
+	status := http.StatusOK
+
+	if rangeable {
+		writer.Header().Set("Accept-Ranges", "bytes")
+	}
+
+	if file.ContentRange != "" {
+		writer.Header().Set("Content-Range", file.ContentRange)
+		status = http.StatusPartialContent
+	}
+
+	if file.Length > 0 {
+		writer.Header().Set("Content-Length", strconv.FormatInt(file.Length, 10))
+	}
+
+	writer.WriteHeader(status)
+	amt, e := io.Copy(writer, file)
 
 	if e == nil || amt > 0 {
 		return
@@ -131,3 +301,29 @@ func (a *displayAPI) renderHTML(writer http.ResponseWriter, report *models.Repor
 
 	log.Printf("strange copy on report html, bytes sent: %d (error: %v)", amt, e)
 }
+
+// redirectHTML redirects the client to a short-lived url of the report's html file when the file store can issue one,
+// returning false (having written nothing) when the html should be served directly instead.
+func (a *displayAPI) redirectHTML(writer http.ResponseWriter, request *http.Request, report *models.Report) bool {
+	signer, ok := a.files.(SigningFileStore)
+
+	if !ok {
+		return false
+	}
+
+	name := path.Join(reportFileDirectory, report.HTMLFileID)
+	location, e := signer.SignFile(name, acceptsEncoding(request, gzipEncoding))
+
+	if e != nil {
+		if e != ErrUnsignable {
+			log.Printf("unable to sign url for report %s: %v", report.SystemID, e)
+		}
+
+		return false
+	}
+
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.Header().Set("Vary", "Accept-Encoding")
+	http.Redirect(writer, request, location, http.StatusFound)
+	return true
+}
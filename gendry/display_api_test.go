@@ -1,16 +1,365 @@
 package gendry
 
+import "io"
+import "os"
+import "fmt"
+import "bytes"
+import "regexp"
 import "testing"
+import "net/url"
+import "io/ioutil"
+import "compress/gzip"
+import "net/http/httptest"
 import "github.com/franela/goblin"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+type testSigningStore struct {
+	*memorystore
+	location string
+	encoded  bool
+}
+
+func (s *testSigningStore) SignFile(name string, encoded bool) (string, error) {
+	if s.location == "" {
+		return "", ErrUnsignable
+	}
+
+	s.encoded = encoded
+	return s.location + name, nil
+}
+
+// testCountingStore counts the files looked up, in full or by range.
+type testCountingStore struct {
+	*localstore
+	lookups int
+}
+
+func (s *testCountingStore) FindFile(name string) (io.ReadCloser, error) {
+	s.lookups++
+	return s.localstore.FindFile(name)
+}
+
+func (s *testCountingStore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	s.lookups++
+	return s.localstore.FindFileRange(name, byteRange)
+}
+
+// testRangeStore serves byte ranges of its content itself, without a seekable reader, the way remote stores do.
+type testRangeStore struct {
+	FileStore
+	content  []byte
+	encoding string
+}
+
+func (s *testRangeStore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	var start, end int64
+
+	if _, e := fmt.Sscanf(byteRange, "bytes=%d-%d", &start, &end); e != nil {
+		reader := ioutil.NopCloser(bytes.NewReader(s.content))
+		return &FileRange{ReadCloser: reader, Length: int64(len(s.content)), Encoding: s.encoding}, nil
+	}
+
+	reader := ioutil.NopCloser(bytes.NewReader(s.content[start : end+1]))
+	contentRange := fmt.Sprintf("bytes %d-%d/%d", start, end, len(s.content))
+	length := end + 1 - start
+	return &FileRange{ReadCloser: reader, ContentRange: contentRange, Length: length, Encoding: s.encoding}, nil
+}
+
+// testGzip returns the gzip compressed content.
+func testGzip(content string) []byte {
+	buffer := new(bytes.Buffer)
+	writer := gzip.NewWriter(buffer)
+	io.WriteString(writer, content)
+	writer.Close()
+	return buffer.Bytes()
+}
 
 func Test_DisplayAPI(t *testing.T) {
 	g := goblin.Goblin(t)
 
 	g.Describe("DisplayAPI", func() {
 		var api *displayAPI
+		var report *models.Report
+		var project *models.Project
 
 		g.BeforeEach(func() {
 			api = &displayAPI{}
+			report = &models.Report{Coverage: 50}
+			project = &models.Project{}
+		})
+
+		g.It("matches trend badge paths with the display api expression", func() {
+			re := regexp.MustCompile(constants.DisplayAPIRegex)
+			match := re.FindStringSubmatch("/reports/gendry/master.trend.svg")
+			g.Assert(match == nil).Equal(false)
+			g.Assert(match[2]).Equal("master")
+			g.Assert(match[3]).Equal("trend.svg")
+		})
+
+		g.It("uses the default style and text when none are provided", func() {
+			b := api.badge(httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), project, report)
+			g.Assert(b.style).Equal(constants.DefaultShieldStyle)
+			g.Assert(b.label).Equal("generated--coverage")
+			g.Assert(b.value).Equal("50.00%")
+		})
+
+		g.It("uses the text and style query params when provided", func() {
+			request := httptest.NewRequest("GET", "/reports/gendry/master.svg?text=cov&style=plastic", nil)
+			b := api.badge(request, project, report)
+			g.Assert(b.style).Equal("plastic")
+			g.Assert(b.label).Equal("cov")
+		})
+
+		g.It("ignores unknown styles", func() {
+			b := api.badge(httptest.NewRequest("GET", "/reports/gendry/master.svg?style=bogus", nil), project, report)
+			g.Assert(b.style).Equal(constants.DefaultShieldStyle)
+		})
+
+		g.It("renders green badges for good coverage", func() {
+			report.Coverage = constants.GoodCoverageAmount + 1
+			b := api.badge(httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), project, report)
+			g.Assert(b.color).Equal("green")
+		})
+
+		g.It("uses the project's coverage thresholds when present", func() {
+			project.CoverageThresholds = "0:red,50:orange,80:green"
+			b := api.badge(httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), project, report)
+			g.Assert(b.color).Equal("orange")
+		})
+
+		g.It("falls back to the default colors if the project's thresholds are invalid", func() {
+			project.CoverageThresholds = "garbage"
+			b := api.badge(httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), project, report)
+			g.Assert(b.color).Equal(constants.DefaultCoverageColor)
+		})
+
+		g.It("counts the badges rendered by format", func() {
+			api.badgeRequests = NewMetrics().Counter("badge_requests_total", "Badges rendered.", "format")
+			api.reportAuthority = reportAuthority{
+				projects: &testProjectPersistence{projects: []*models.Project{{Name: "gendry", SystemID: "project-1"}}},
+				reports:  &testReportPersistence{reports: []*models.Report{{ProjectID: "project-1", Tag: "master"}}},
+			}
+
+			params := url.Values{"project": {"gendry"}, "tag": {"master"}, "format": {"svg"}}
+			recorder := httptest.NewRecorder()
+			api.Get(recorder, httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), params)
+
+			g.Assert(recorder.Code).Equal(200)
+			g.Assert(testutil.ToFloat64(api.badgeRequests.vec.WithLabelValues("svg"))).Equal(float64(1))
+		})
+
+		g.It("counts badges answered from the client's cache", func() {
+			api.badgeCache = NewMetrics().Counter("badge_cache_total", "Badge cache results.", "result")
+			shield := &badge{label: "coverage", value: "50.00%", style: "flat"}
+			first := httptest.NewRecorder()
+			api.renderBadge(first, httptest.NewRequest("GET", "/reports/gendry/master.svg", nil), shield)
+
+			request := httptest.NewRequest("GET", "/reports/gendry/master.svg", nil)
+			request.Header.Set("If-None-Match", first.Header().Get("ETag"))
+			second := httptest.NewRecorder()
+			api.renderBadge(second, request, shield)
+
+			g.Assert(first.Code).Equal(200)
+			g.Assert(second.Code).Equal(304)
+			g.Assert(second.Body.Len()).Equal(0)
+			g.Assert(testutil.ToFloat64(api.badgeCache.vec.WithLabelValues("hit"))).Equal(float64(1))
+			g.Assert(testutil.ToFloat64(api.badgeCache.vec.WithLabelValues("miss"))).Equal(float64(1))
+		})
+
+		g.Describe("renderHTML", func() {
+			var store *memorystore
+
+			g.BeforeEach(func() {
+				store = newMemoryStore(0, 0)
+				id, writer, _ := store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html>coverage</html>")
+				writer.Close()
+				report.HTMLFileID = id
+				api.files = store
+			})
+
+			g.It("renders the entire file", func() {
+				recorder := httptest.NewRecorder()
+				api.renderHTML(recorder, httptest.NewRequest("GET", "/reports/gendry/master.html", nil), report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+			})
+
+			g.It("renders the requested byte range of the file", func() {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				request.Header.Set("Range", "bytes=6-13")
+				api.renderHTML(recorder, request, report)
+				g.Assert(recorder.Code).Equal(206)
+				g.Assert(recorder.Body.String()).Equal("coverage")
+				g.Assert(recorder.Header().Get("Content-Range")).Equal("bytes 6-13/21")
+			})
+
+			g.It("renders the report with a header colored by the project's current thresholds", func() {
+				store.DeleteFile("reports/" + report.HTMLFileID)
+				id, writer, _ := store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html><body>coverage</body></html>")
+				writer.Close()
+				report.HTMLFileID = id
+				project.CoverageThresholds = "0:red,40:orange"
+
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				api.renderReport(recorder, request, project, report)
+				header := reportHeader(50, coverageThresholds{{Minimum: 40, Color: "orange"}})
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("<html><body>" + header + "coverage</body></html>")
+
+				stored, _ := store.FindFile("reports/" + id)
+				content, _ := ioutil.ReadAll(stored)
+				g.Assert(string(content)).Equal("<html><body>coverage</body></html>")
+			})
+
+			g.It("renders the report as it was uploaded when the raw file is requested", func() {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html?raw=true", nil)
+				api.renderReport(recorder, request, project, report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+			})
+
+			g.It("renders a not found response when the file is missing", func() {
+				report.HTMLFileID = "missing"
+				recorder := httptest.NewRecorder()
+				api.renderHTML(recorder, httptest.NewRequest("GET", "/reports/gendry/master.html", nil), report)
+				g.Assert(recorder.Code).Equal(404)
+			})
+		})
+
+		g.Describe("renderHTML with compressed files", func() {
+			var root string
+			var store *testCountingStore
+
+			g.BeforeEach(func() {
+				root, _ = ioutil.TempDir("", "gendry-display-api")
+				persistence := &testFilePersistence{files: make(map[string]*models.File)}
+				local := &localstore{root: root, compression: "gzip", persistence: persistence}
+				store = &testCountingStore{localstore: local}
+				id, writer, _ := store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html>coverage</html>")
+				writer.Close()
+				report.HTMLFileID = id
+				api.files = store
+			})
+
+			g.AfterEach(func() {
+				os.RemoveAll(root)
+			})
+
+			g.It("serves the compressed file to clients accepting gzip", func() {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				request.Header.Set("Accept-Encoding", "deflate, gzip")
+				api.renderHTML(recorder, request, report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Header().Get("Content-Encoding")).Equal("gzip")
+
+				reader, e := gzip.NewReader(recorder.Body)
+				g.Assert(e).Equal(nil)
+				content, _ := ioutil.ReadAll(reader)
+				g.Assert(string(content)).Equal("<html>coverage</html>")
+			})
+
+			g.It("decompresses the file for clients that do not accept gzip", func() {
+				recorder := httptest.NewRecorder()
+				api.renderHTML(recorder, httptest.NewRequest("GET", "/reports/gendry/master.html", nil), report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Header().Get("Content-Encoding")).Equal("")
+				g.Assert(recorder.Header().Get("Vary")).Equal("Accept-Encoding")
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+				g.Assert(store.lookups).Equal(1)
+			})
+
+			g.It("sends the whole decompressed file to clients that do not accept gzip, ignoring ranges", func() {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				request.Header.Set("Range", "bytes=6-13")
+				api.renderHTML(recorder, request, report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+				g.Assert(store.lookups).Equal(1)
+			})
+		})
+
+		g.Describe("renderHTML with a file store serving ranges", func() {
+			var store *testRangeStore
+
+			g.BeforeEach(func() {
+				store = &testRangeStore{content: []byte("<html>coverage</html>")}
+				api.files = store
+			})
+
+			fetch := func(encoding string) *httptest.ResponseRecorder {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				request.Header.Set("Range", "bytes=6-13")
+				request.Header.Set("Accept-Encoding", encoding)
+				api.renderHTML(recorder, request, report)
+				return recorder
+			}
+
+			g.It("renders the requested byte range of uncompressed files to clients that do not accept gzip", func() {
+				recorder := fetch("")
+				g.Assert(recorder.Code).Equal(206)
+				g.Assert(recorder.Body.String()).Equal("coverage")
+				g.Assert(recorder.Header().Get("Content-Range")).Equal("bytes 6-13/21")
+			})
+
+			g.It("renders the requested byte range of compressed files to clients accepting gzip", func() {
+				store.content, store.encoding = testGzip("<html>coverage</html>"), gzipEncoding
+				recorder := fetch("gzip")
+				g.Assert(recorder.Code).Equal(206)
+				g.Assert(recorder.Header().Get("Content-Encoding")).Equal("gzip")
+				g.Assert(recorder.Body.Bytes()).Equal(store.content[6:14])
+			})
+
+			g.It("sends the whole decompressed file to clients that do not accept gzip", func() {
+				store.content, store.encoding = testGzip("<html>coverage</html>"), gzipEncoding
+				recorder := fetch("")
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Header().Get("Content-Range")).Equal("")
+				g.Assert(recorder.Header().Get("Accept-Ranges")).Equal("none")
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+			})
+		})
+
+		g.Describe("renderHTML with a signing file store", func() {
+			var store *testSigningStore
+
+			g.BeforeEach(func() {
+				store = &testSigningStore{memorystore: newMemoryStore(0, 0), location: "https://bucket.example.com/"}
+				id, writer, _ := store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html>coverage</html>")
+				writer.Close()
+				report.HTMLFileID = id
+				api.files = store
+			})
+
+			g.It("redirects to the signed url of the file", func() {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest("GET", "/reports/gendry/master.html", nil)
+				request.Header.Set("Accept-Encoding", "gzip")
+				api.renderHTML(recorder, request, report)
+				g.Assert(recorder.Code).Equal(302)
+				location := "https://bucket.example.com/reports/" + report.HTMLFileID
+				g.Assert(recorder.Header().Get("Location")).Equal(location)
+				g.Assert(store.encoded).Equal(true)
+			})
+
+			g.It("serves the file directly when the store cannot sign a url", func() {
+				store.location = ""
+				recorder := httptest.NewRecorder()
+				api.renderHTML(recorder, httptest.NewRequest("GET", "/reports/gendry/master.html", nil), report)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("<html>coverage</html>")
+			})
 		})
 	})
 }
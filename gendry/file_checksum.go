@@ -0,0 +1,104 @@
+package gendry
+
+import "io"
+import "fmt"
+import "hash"
+import "crypto/sha256"
+import "encoding/hex"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// ErrChecksumMismatch is returned while reading a file whose content does not match its recorded checksum and size.
+var ErrChecksumMismatch = fmt.Errorf("checksum-mismatch")
+
+// checksumWriter tracks the sha-256 checksum and size of everything written through it.
+type checksumWriter struct {
+	hash hash.Hash
+	size int64
+}
+
+func newChecksumWriter() *checksumWriter {
+	return &checksumWriter{hash: sha256.New()}
+}
+
+func (w *checksumWriter) Write(data []byte) (int, error) {
+	w.size += int64(len(data))
+	return w.hash.Write(data)
+}
+
+// sum returns the hex encoded checksum of the content written so far.
+func (w *checksumWriter) sum() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+// verifyingReader checks the content read sequentially from the start of a file against the file's checksum and size,
+// returning ErrChecksumMismatch once the content is known to differ. Reads that do not continue from the content
+// already checked (e.g. range requests) disable the verification.
+type verifyingReader struct {
+	io.ReadCloser
+	checksum   *checksumWriter
+	expected   *models.File
+	position   int64
+	verifiable bool
+}
+
+// seekableVerifyingReader is a verifying reader over a seekable file, preserving the ability to serve ranges.
+type seekableVerifyingReader struct {
+	*verifyingReader
+}
+
+// verifyFile wraps the reader of the file when its record has a checksum, otherwise returning the reader as-is.
+func verifyFile(reader io.ReadCloser, record *models.File) io.ReadCloser {
+	if record == nil || record.Checksum == "" {
+		return reader
+	}
+
+	verifier := &verifyingReader{
+		ReadCloser: reader,
+		checksum:   newChecksumWriter(),
+		expected:   record,
+		verifiable: true,
+	}
+
+	if _, ok := reader.(io.Seeker); ok {
+		return seekableVerifyingReader{verifier}
+	}
+
+	return verifier
+}
+
+func (r *verifyingReader) Read(data []byte) (int, error) {
+	if r.position != r.checksum.size {
+		r.verifiable = false
+	}
+
+	n, e := r.ReadCloser.Read(data)
+	r.position += int64(n)
+
+	if r.verifiable != true {
+		return n, e
+	}
+
+	verified := r.checksum.size == r.expected.Size
+	r.checksum.Write(data[:n])
+
+	switch {
+	case r.checksum.size > r.expected.Size:
+		return n, ErrChecksumMismatch
+	case r.checksum.size == r.expected.Size && verified != true && r.checksum.sum() != r.expected.Checksum:
+		return n, ErrChecksumMismatch
+	case r.checksum.size < r.expected.Size && e == io.EOF:
+		return n, ErrChecksumMismatch
+	}
+
+	return n, e
+}
+
+func (r seekableVerifyingReader) Seek(offset int64, whence int) (int64, error) {
+	position, e := r.ReadCloser.(io.Seeker).Seek(offset, whence)
+
+	if e == nil {
+		r.position = position
+	}
+
+	return position, e
+}
@@ -0,0 +1,86 @@
+package gendry
+
+import "io"
+import "strings"
+import "testing"
+import "io/ioutil"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+type testSeekableFile struct {
+	*strings.Reader
+}
+
+func (f testSeekableFile) Close() error {
+	return nil
+}
+
+func Test_FileChecksum(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	checksum := func(content string) *models.File {
+		writer := newChecksumWriter()
+		io.WriteString(writer, content)
+		return &models.File{Checksum: writer.sum(), Size: writer.size}
+	}
+
+	g.Describe("checksumWriter", func() {
+		g.It("computes the sha-256 checksum and size of the content", func() {
+			writer := newChecksumWriter()
+			io.WriteString(writer, "hello")
+			g.Assert(writer.size).Equal(int64(5))
+			g.Assert(writer.sum()).Equal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+		})
+	})
+
+	g.Describe("verifyFile", func() {
+		g.It("returns the reader unchanged when the record has no checksum", func() {
+			reader := ioutil.NopCloser(strings.NewReader("hello"))
+			g.Assert(verifyFile(reader, &models.File{}) == reader).Equal(true)
+			g.Assert(verifyFile(reader, nil) == reader).Equal(true)
+		})
+
+		g.It("reads content matching the checksum", func() {
+			reader := verifyFile(ioutil.NopCloser(strings.NewReader("hello")), checksum("hello"))
+			content, e := ioutil.ReadAll(reader)
+			g.Assert(e).Equal(nil)
+			g.Assert(string(content)).Equal("hello")
+		})
+
+		g.It("returns an error for corrupted content", func() {
+			_, e := ioutil.ReadAll(verifyFile(ioutil.NopCloser(strings.NewReader("jello")), checksum("hello")))
+			g.Assert(e).Equal(ErrChecksumMismatch)
+		})
+
+		g.It("returns an error for truncated content", func() {
+			_, e := ioutil.ReadAll(verifyFile(ioutil.NopCloser(strings.NewReader("hell")), checksum("hello")))
+			g.Assert(e).Equal(ErrChecksumMismatch)
+		})
+
+		g.It("returns an error for content longer than the recorded size", func() {
+			_, e := ioutil.ReadAll(verifyFile(ioutil.NopCloser(strings.NewReader("hello!")), checksum("hello")))
+			g.Assert(e).Equal(ErrChecksumMismatch)
+		})
+
+		g.It("preserves the ability to seek files", func() {
+			_, ok := verifyFile(testSeekableFile{strings.NewReader("hello")}, checksum("hello")).(io.ReadSeeker)
+			g.Assert(ok).Equal(true)
+		})
+
+		g.It("verifies seekable files read from the start", func() {
+			reader := verifyFile(testSeekableFile{strings.NewReader("jello")}, checksum("hello")).(io.ReadSeeker)
+			reader.Seek(0, io.SeekEnd)
+			reader.Seek(0, io.SeekStart)
+			_, e := reader.Read(make([]byte, 5))
+			g.Assert(e).Equal(ErrChecksumMismatch)
+		})
+
+		g.It("does not verify partial reads", func() {
+			reader := verifyFile(testSeekableFile{strings.NewReader("jello")}, checksum("hello")).(io.ReadSeeker)
+			reader.Seek(1, io.SeekStart)
+			content, e := ioutil.ReadAll(reader)
+			g.Assert(e).Equal(nil)
+			g.Assert(string(content)).Equal("ello")
+		})
+	})
+}
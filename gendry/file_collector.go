@@ -0,0 +1,202 @@
+package gendry
+
+import "path"
+import "time"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// FileCollector removes stored files that are no longer, or never became, usable.
+type FileCollector interface {
+	Collect(bool) (*FileCollection, error)
+}
+
+// FileCollection reports the files found (and, unless it was a dry run, removed) by a collection.
+type FileCollection struct {
+	DryRun       bool     `json:"dry_run"`
+	Pending      []string `json:"pending"`
+	Unreferenced []string `json:"unreferenced"`
+	Orphaned     []string `json:"orphaned"`
+}
+
+// Total returns the amount of files found by the collection.
+func (c *FileCollection) Total() int {
+	return len(c.Pending) + len(c.Unreferenced) + len(c.Orphaned)
+}
+
+// collectionPageSize is the amount of file records (or stored objects) checked with each query during a collection.
+const collectionPageSize = 500
+
+// NewFileCollector returns a collector that only considers files older than the minimum age, giving in-flight uploads
+// time to complete and be referenced by their report.
+func NewFileCollector(
+	files models.FileStore, reports models.ReportStore, store FileStore, minimumAge time.Duration, log LeveledLogger,
+) FileCollector {
+	collector := &fileCollector{
+		LeveledLogger: log,
+		files:         files,
+		reports:       reports,
+		store:         store,
+		minimumAge:    minimumAge,
+		pageSize:      collectionPageSize,
+		now:           time.Now,
+	}
+
+	return collector
+}
+
+type fileCollector struct {
+	LeveledLogger
+	files      models.FileStore
+	reports    models.ReportStore
+	store      FileStore
+	minimumAge time.Duration
+	pageSize   int
+	now        func() time.Time
+}
+
+// Collect finds files stuck in the PENDING state, valid files not referenced by any report and stored objects without
+// a file record, removing them unless this is a dry run. Records are loaded a page at a time.
+func (c *fileCollector) Collect(dryRun bool) (*FileCollection, error) {
+	cutoff := c.now().Add(-c.minimumAge)
+	result := &FileCollection{DryRun: dryRun, Pending: []string{}, Unreferenced: []string{}, Orphaned: []string{}}
+
+	for offset := 0; ; offset += c.pageSize {
+		records, e := c.files.FindFiles(&models.FileBlueprint{
+			CreatedAtRange: []time.Time{{}, cutoff},
+			OrderBy:        "id",
+			OrderDirection: "ASC",
+			Limit:          c.pageSize,
+			Offset:         offset,
+		})
+
+		if e != nil {
+			return nil, e
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		if e := c.classify(records, result); e != nil {
+			return nil, e
+		}
+
+		if len(records) < c.pageSize {
+			break
+		}
+	}
+
+	if lister, ok := c.store.(FileLister); ok {
+		stored, e := lister.ListFiles(reportFileDirectory)
+
+		if e != nil {
+			return nil, e
+		}
+
+		candidates := make([]StoredFile, 0, len(stored))
+
+		for _, f := range stored {
+			if f.ModifiedAt.After(cutoff) != true {
+				candidates = append(candidates, f)
+			}
+		}
+
+		for start := 0; start < len(candidates); start += c.pageSize {
+			end := start + c.pageSize
+
+			if end > len(candidates) {
+				end = len(candidates)
+			}
+
+			orphaned, e := c.orphans(candidates[start:end])
+
+			if e != nil {
+				return nil, e
+			}
+
+			result.Orphaned = append(result.Orphaned, orphaned...)
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	names := make([]string, 0, result.Total())
+
+	for _, id := range append(result.Pending, result.Unreferenced...) {
+		names = append(names, path.Join(reportFileDirectory, id))
+	}
+
+	for _, name := range append(names, result.Orphaned...) {
+		if e := c.store.DeleteFile(name); e != nil {
+			return nil, e
+		}
+
+		c.Debugf("collected file %s", name)
+	}
+
+	return result, nil
+}
+
+// classify adds the pending files, along with the valid files that are not referenced by a report, to the result.
+func (c *fileCollector) classify(records []*models.File, result *FileCollection) error {
+	ids := make([]string, len(records))
+
+	for i, f := range records {
+		ids[i] = f.SystemID
+	}
+
+	referenced, e := c.reports.SelectHTMLFileIDs(&models.ReportBlueprint{HTMLFileID: ids})
+
+	if e != nil {
+		return e
+	}
+
+	references := make(map[string]bool, len(referenced))
+
+	for _, id := range referenced {
+		references[id] = true
+	}
+
+	for _, f := range records {
+		switch {
+		case f.Status == "PENDING":
+			result.Pending = append(result.Pending, f.SystemID)
+		case references[f.SystemID] != true:
+			result.Unreferenced = append(result.Unreferenced, f.SystemID)
+		}
+	}
+
+	return nil
+}
+
+// orphans returns the names of the stored objects that are neither a file nor the object holding a file's content.
+func (c *fileCollector) orphans(stored []StoredFile) ([]string, error) {
+	names := make([]string, len(stored))
+
+	for i, f := range stored {
+		names[i] = path.Base(f.Name)
+	}
+
+	records, e := c.files.FindFiles(&models.FileBlueprint{SystemID: names, ObjectID: names, Inclusive: true})
+
+	if e != nil {
+		return nil, e
+	}
+
+	known := make(map[string]bool, len(records)*2)
+
+	for _, f := range records {
+		known[f.SystemID], known[objectOf(f)] = true, true
+	}
+
+	results := make([]string, 0, len(stored))
+
+	for _, f := range stored {
+		if known[path.Base(f.Name)] != true {
+			results = append(results, f.Name)
+		}
+	}
+
+	return results, nil
+}
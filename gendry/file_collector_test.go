@@ -0,0 +1,95 @@
+package gendry
+
+import "io"
+import "os"
+import "time"
+import "testing"
+import "io/ioutil"
+import "path/filepath"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_FileCollector(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("fileCollector", func() {
+		var collector *fileCollector
+		var persistence *testFilePersistence
+		var reports *testReportPersistence
+		var store *localstore
+		var root string
+		var valid, referenced, pending, orphan string
+
+		write := func(content string) string {
+			id, writer, _ := store.NewFile("text/html", reportFileDirectory)
+			io.WriteString(writer, content)
+			writer.Close()
+			return id
+		}
+
+		g.BeforeEach(func() {
+			root, _ = ioutil.TempDir("", "gendry-file-collector")
+			persistence = &testFilePersistence{files: make(map[string]*models.File)}
+			store = &localstore{root: root, persistence: persistence}
+
+			valid, referenced, orphan = write("valid"), write("referenced"), write("orphan")
+			pending, _, _ = store.NewFile("text/html", reportFileDirectory)
+			delete(persistence.files, orphan)
+
+			reports = &testReportPersistence{reports: []*models.Report{{SystemID: "a", HTMLFileID: referenced}}}
+			collector = &fileCollector{
+				LeveledLogger: &testLogger{},
+				files:         persistence,
+				reports:       reports,
+				store:         store,
+				minimumAge:    time.Hour,
+				pageSize:      collectionPageSize,
+				now:           func() time.Time { return time.Now().Add(2 * time.Hour) },
+			}
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(root)
+		})
+
+		g.It("finds pending, unreferenced and orphaned files without removing them during a dry run", func() {
+			result, e := collector.Collect(true)
+			g.Assert(e).Equal(nil)
+			g.Assert(result.Pending).Equal([]string{pending})
+			g.Assert(result.Unreferenced).Equal([]string{valid})
+			g.Assert(len(result.Orphaned)).Equal(2)
+			g.Assert(len(persistence.files)).Equal(3)
+
+			_, e = os.Stat(filepath.Join(root, reportFileDirectory, orphan))
+			g.Assert(e).Equal(nil)
+		})
+
+		g.It("removes the files it finds", func() {
+			result, e := collector.Collect(false)
+			g.Assert(e).Equal(nil)
+			g.Assert(result.Total()).Equal(4)
+			g.Assert(len(persistence.files)).Equal(1)
+
+			files, _ := store.ListFiles(reportFileDirectory)
+			g.Assert(len(files)).Equal(1)
+			g.Assert(files[0].Name).Equal(reportFileDirectory + "/" + referenced)
+		})
+
+		g.It("checks the records a page at a time", func() {
+			collector.pageSize = 1
+			result, e := collector.Collect(true)
+			g.Assert(e).Equal(nil)
+			g.Assert(result.Pending).Equal([]string{pending})
+			g.Assert(result.Unreferenced).Equal([]string{valid})
+			g.Assert(len(result.Orphaned)).Equal(2)
+			g.Assert(persistence.queries > len(persistence.files)).Equal(true)
+		})
+
+		g.It("ignores files younger than the minimum age", func() {
+			collector.now = time.Now
+			result, e := collector.Collect(false)
+			g.Assert(e).Equal(nil)
+			g.Assert(result.Total()).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package gendry
+
+import "io"
+import "fmt"
+import "strings"
+import "strconv"
+import "net/http"
+import "compress/gzip"
+
+const gzipEncoding = "gzip"
+
+// storedEncoding returns the encoding files are stored with given the configured compression; gzip is the only
+// supported compression, anything else stores files as-is.
+func storedEncoding(compression string) string {
+	if compression == gzipEncoding {
+		return gzipEncoding
+	}
+
+	return ""
+}
+
+// encodedWriter encodes content written through it into the destination, tracking the checksum and size of the
+// original content. Closing the writer flushes the encoder but leaves the destination open.
+type encodedWriter struct {
+	destination io.Writer
+	encoder     io.WriteCloser
+	checksum    *checksumWriter
+}
+
+func newEncodedWriter(destination io.Writer, encoding string) *encodedWriter {
+	writer := &encodedWriter{destination: destination, checksum: newChecksumWriter()}
+
+	if encoding == gzipEncoding {
+		writer.encoder = gzip.NewWriter(destination)
+	}
+
+	return writer
+}
+
+func (w *encodedWriter) Write(data []byte) (int, error) {
+	var n int
+	var e error
+
+	if w.encoder != nil {
+		n, e = w.encoder.Write(data)
+	} else {
+		n, e = w.destination.Write(data)
+	}
+
+	w.checksum.Write(data[:n])
+	return n, e
+}
+
+func (w *encodedWriter) Close() error {
+	if w.encoder == nil {
+		return nil
+	}
+
+	return w.encoder.Close()
+}
+
+// decodedReader reads the original content of an encoded file, closing the underlying file when closed.
+type decodedReader struct {
+	io.Reader
+	file io.Closer
+}
+
+func (r *decodedReader) Close() error {
+	return r.file.Close()
+}
+
+// decodeFile wraps the reader of a file stored with the encoding so that it returns the file's original content.
+func decodeFile(reader io.ReadCloser, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "":
+		return reader, nil
+	case gzipEncoding:
+		decoder, e := gzip.NewReader(reader)
+
+		if e != nil {
+			reader.Close()
+			return nil, e
+		}
+
+		return &decodedReader{Reader: decoder, file: reader}, nil
+	}
+
+	reader.Close()
+	return nil, fmt.Errorf("invalid-encoding: %s", encoding)
+}
+
+// acceptsEncoding returns whether the request's Accept-Encoding header allows a response with the content encoding. An
+// entry naming the encoding takes precedence over the "*" wildcard.
+func acceptsEncoding(request *http.Request, encoding string) bool {
+	wildcard := false
+
+	for _, accepted := range strings.Split(request.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(accepted, ";")
+		name, allowed := strings.TrimSpace(parts[0]), true
+
+		if len(parts) > 1 {
+			quality := strings.TrimPrefix(strings.TrimSpace(parts[1]), "q=")
+			value, e := strconv.ParseFloat(quality, 64)
+			allowed = e != nil || value > 0
+		}
+
+		switch name {
+		case encoding:
+			return allowed
+		case "*":
+			wildcard = allowed
+		}
+	}
+
+	return wildcard
+}
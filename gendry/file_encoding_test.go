@@ -0,0 +1,88 @@
+package gendry
+
+import "io"
+import "bytes"
+import "testing"
+import "io/ioutil"
+import "net/http/httptest"
+import "github.com/franela/goblin"
+
+func Test_FileEncoding(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("storedEncoding", func() {
+		g.It("only supports gzip", func() {
+			g.Assert(storedEncoding("gzip")).Equal("gzip")
+			g.Assert(storedEncoding("none")).Equal("")
+			g.Assert(storedEncoding("zstd")).Equal("")
+		})
+	})
+
+	g.Describe("encodedWriter", func() {
+		g.It("writes content as-is without an encoding", func() {
+			output := new(bytes.Buffer)
+			writer := newEncodedWriter(output, "")
+			io.WriteString(writer, "hello")
+			g.Assert(writer.Close()).Equal(nil)
+			g.Assert(output.String()).Equal("hello")
+		})
+
+		g.It("compresses content that decodes to the original content", func() {
+			output := new(bytes.Buffer)
+			writer := newEncodedWriter(output, "gzip")
+			io.WriteString(writer, "hello")
+			g.Assert(writer.Close()).Equal(nil)
+			g.Assert(output.String() == "hello").Equal(false)
+
+			reader, e := decodeFile(ioutil.NopCloser(output), "gzip")
+			g.Assert(e).Equal(nil)
+			content, _ := ioutil.ReadAll(reader)
+			g.Assert(string(content)).Equal("hello")
+		})
+
+		g.It("tracks the checksum and size of the original content", func() {
+			writer := newEncodedWriter(new(bytes.Buffer), "gzip")
+			io.WriteString(writer, "hello")
+			g.Assert(writer.checksum.size).Equal(int64(5))
+			g.Assert(writer.checksum.sum()).Equal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+		})
+	})
+
+	g.Describe("decodeFile", func() {
+		g.It("returns an error for unknown encodings", func() {
+			_, e := decodeFile(ioutil.NopCloser(new(bytes.Buffer)), "zstd")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for content that is not compressed", func() {
+			_, e := decodeFile(ioutil.NopCloser(bytes.NewBufferString("hello")), "gzip")
+			g.Assert(e == nil).Equal(false)
+		})
+	})
+
+	g.Describe("acceptsEncoding", func() {
+		accepts := func(header string) bool {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.Header.Set("Accept-Encoding", header)
+			return acceptsEncoding(request, "gzip")
+		}
+
+		g.It("accepts listed encodings", func() {
+			g.Assert(accepts("gzip")).Equal(true)
+			g.Assert(accepts("deflate, gzip;q=0.5")).Equal(true)
+			g.Assert(accepts("*")).Equal(true)
+		})
+
+		g.It("rejects missing or refused encodings", func() {
+			g.Assert(accepts("")).Equal(false)
+			g.Assert(accepts("deflate, br")).Equal(false)
+			g.Assert(accepts("gzip;q=0")).Equal(false)
+		})
+
+		g.It("prefers entries naming the encoding over the wildcard", func() {
+			g.Assert(accepts("*;q=1, gzip;q=0")).Equal(false)
+			g.Assert(accepts("gzip;q=0, *")).Equal(false)
+			g.Assert(accepts("*;q=0, gzip")).Equal(true)
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package gendry
+
+import "path"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// Stored content is addressed by its checksum: a file whose content is identical to an already stored file shares that
+// file's object rather than storing another copy. Every file record names the object holding its content, and objects
+// are only removed once no record references them.
+
+// recordContent persists the checksum and size of a fully written file, returning the id of the object that should
+// hold its content; either the object of an existing file with identical content (stored with the same encoding) or,
+// for new content, the file's own.
+func recordContent(persistence models.FileStore, id, encoding string, checksum *checksumWriter) (string, error) {
+	blueprint := &models.FileBlueprint{
+		SystemID: []string{id},
+	}
+
+	if _, e, _ := persistence.UpdateFileChecksum(checksum.sum(), blueprint); e != nil {
+		return "", e
+	}
+
+	if _, e, _ := persistence.UpdateFileSize(checksum.size, blueprint); e != nil {
+		return "", e
+	}
+
+	duplicates, e := persistence.FindFiles(&models.FileBlueprint{
+		Checksum: []string{checksum.sum()},
+		Size:     []int64{checksum.size},
+		Status:   []string{"VALID"},
+		Encoding: []string{encoding},
+		Limit:    1,
+	})
+
+	if e != nil || len(duplicates) == 0 {
+		return id, e
+	}
+
+	object := objectOf(duplicates[0])
+
+	if _, e, _ := persistence.UpdateFileObjectID(object, blueprint); e != nil {
+		return "", e
+	}
+
+	// The object may have been released (and removed) while it was being linked; unless another record still
+	// references it after the update, the file keeps its own content instead.
+	references, e := countReferences(persistence, object)
+
+	if e != nil || references > 1 {
+		return object, e
+	}
+
+	if _, e, _ := persistence.UpdateFileObjectID(id, blueprint); e != nil {
+		return "", e
+	}
+
+	return id, nil
+}
+
+// validateFile marks the file's record valid once its content is in place.
+func validateFile(persistence models.FileStore, id string) error {
+	blueprint := &models.FileBlueprint{
+		SystemID: []string{id},
+	}
+
+	_, e, _ := persistence.UpdateFileStatus("VALID", blueprint)
+	return e
+}
+
+// objectOf returns the id of the object holding the file's content; records created before content addressing hold
+// their own object.
+func objectOf(record *models.File) string {
+	if record.ObjectID == "" {
+		return record.SystemID
+	}
+
+	return record.ObjectID
+}
+
+// objectName returns the name of the object holding the content of the named file, along with the file's record.
+// Files without a record are assumed to be objects themselves.
+func objectName(persistence models.FileStore, name string) (string, *models.File) {
+	record := findFileRecord(persistence, path.Base(name))
+
+	if record == nil {
+		return name, nil
+	}
+
+	return path.Join(path.Dir(name), objectOf(record)), record
+}
+
+// releaseFile deletes the record of the named file, returning the name of the object holding its content along with
+// whether the object is no longer referenced by any record and can be removed. Files without a record (e.g. an owner
+// whose record was already released) are still checked, so content shared with other records is never removed.
+func releaseFile(persistence models.FileStore, name string) (string, bool, error) {
+	object, record := objectName(persistence, name)
+
+	if record != nil {
+		if _, e := persistence.DeleteFiles(&models.FileBlueprint{SystemID: []string{record.SystemID}}); e != nil {
+			return "", false, e
+		}
+	}
+
+	references, e := countReferences(persistence, path.Base(object))
+
+	if e != nil {
+		return "", false, e
+	}
+
+	return object, references == 0, nil
+}
+
+// countReferences returns the amount of records holding their content in the object.
+func countReferences(persistence models.FileStore, object string) (int, error) {
+	return persistence.CountFiles(&models.FileBlueprint{
+		SystemID:  []string{object},
+		ObjectID:  []string{object},
+		Inclusive: true,
+	})
+}
+
+// findFileRecord returns the persisted record of the file, or nil if it cannot be found.
+func findFileRecord(persistence models.FileStore, id string) *models.File {
+	records, e := persistence.FindFiles(&models.FileBlueprint{SystemID: []string{id}})
+
+	if e != nil || len(records) != 1 {
+		return nil
+	}
+
+	return records[0]
+}
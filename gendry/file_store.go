@@ -1,19 +1,11 @@
 package gendry
 
 import "io"
-import "log"
 import "fmt"
-import "path"
-import "bytes"
+import "time"
+import "strconv"
 import "net/url"
 import "database/sql"
-import "github.com/satori/go.uuid"
-import "github.com/aws/aws-sdk-go/aws"
-import "github.com/aws/aws-sdk-go/service/s3"
-import "github.com/aws/aws-sdk-go/aws/session"
-import "github.com/aws/aws-sdk-go/aws/credentials"
-import "github.com/aws/aws-sdk-go/service/s3/s3manager"
-
 import "github.com/dadleyy/gendry/gendry/models"
 import "github.com/dadleyy/gendry/gendry/constants"
 
@@ -21,142 +13,97 @@ import "github.com/dadleyy/gendry/gendry/constants"
 type FileStore interface {
 	NewFile(string, string) (string, io.WriteCloser, error)
 	FindFile(string) (io.ReadCloser, error)
+	DeleteFile(string) error
 }
 
-// NewFileStore returns an implementation of the FileStore interface.
-func NewFileStore(driver string, configuration *url.Values, db *sql.DB) FileStore {
-	switch driver {
-	case "s3":
-		store := &s3store{
-			accessID:    configuration.Get(constants.AWSAccessKeyIDEnvVariable),
-			accessToken: configuration.Get(constants.AWSAccessTokenEnvVariable),
-			accessKey:   configuration.Get(constants.AWSAccessKeyEnvVariable),
-			bucketName:  configuration.Get(constants.AWSBucketNameEnvVariable),
-			persistence: models.NewFileStore(db),
-			region:      configuration.Get("AWS_REGION"),
-		}
-		return store
-	default:
-		return &tempstore{}
-	}
-}
+// ErrInvalidRange is returned by range file stores when the requested range cannot be satisfied.
+var ErrInvalidRange = fmt.Errorf("invalid-range")
 
-type tempstore struct {
+// FileRange is a (possibly partial) read of a file as it is stored; a content range is only present for partial reads
+// and the encoding is only present for files stored encoded (e.g. compressed).
+type FileRange struct {
+	io.ReadCloser
+	ContentRange string
+	Length       int64
+	Encoding     string
 }
 
-func (s *tempstore) FindFile(string) (io.ReadCloser, error) {
-	return nil, fmt.Errorf("not-implmented")
+// RangeFileStore is implemented by file stores that can read a byte range (an http Range header value) of a file as
+// it is stored. Stores returning seekable readers may leave serving the range to the caller.
+type RangeFileStore interface {
+	FindFileRange(string, string) (*FileRange, error)
 }
 
-func (s *tempstore) NewFile(string, string) (string, io.WriteCloser, error) {
-	return "", nil, fmt.Errorf("not-implmented")
+// StoredFile describes a file held by a file store, named by its path within the store.
+type StoredFile struct {
+	Name       string
+	ModifiedAt time.Time
 }
 
-type s3store struct {
-	accessID    string
-	accessToken string
-	accessKey   string
-	bucketName  string
-	region      string
-	persistence models.FileStore
+// FileLister is implemented by file stores able to enumerate the files held beneath a directory.
+type FileLister interface {
+	ListFiles(string) ([]StoredFile, error)
 }
 
-func (s *s3store) NewFile(contentType string, directory string) (string, io.WriteCloser, error) {
-	uploadSession, e := s.newSession()
-
-	if e != nil {
-		return "", nil, e
-	}
-
-	uploader := s3manager.NewUploader(uploadSession)
-
-	pr, pw := io.Pipe()
-	id := fmt.Sprintf("%s", uuid.NewV4())
-
-	record := models.File{
-		SystemID: id,
-		Status:   "PENDING",
-	}
-
-	if _, e := s.persistence.CreateFiles(record); e != nil {
-		return "", nil, e
-	}
-
-	go func() {
-		key := path.Join(directory, id)
-
-		input := &s3manager.UploadInput{
-			Bucket:      aws.String(s.bucketName),
-			Key:         aws.String(key),
-			ContentType: aws.String(contentType),
-			Body:        pr,
-		}
-
-		if _, e := uploader.Upload(input); e != nil {
-			pr.CloseWithError(fmt.Errorf("unable to put object into s3 (error: %v)", e))
-			return
-		}
-
-		blueprint := &models.FileBlueprint{
-			SystemID: []string{id},
-		}
-
-		if _, e, _ := s.persistence.UpdateFileStatus("VALID", blueprint); e != nil {
-			pr.CloseWithError(e)
-			return
-		}
+// ErrUnsignable is returned by signing file stores when a url cannot be issued for the file.
+var ErrUnsignable = fmt.Errorf("unsignable")
 
-		pr.Close()
-	}()
+// SigningFileStore is implemented by file stores able to issue short-lived urls that clients can download files from
+// directly. The flag indicates whether the url may serve the file encoded as it is stored.
+type SigningFileStore interface {
+	SignFile(string, bool) (string, error)
+}
 
-	return id, pw, nil
+// fullFileStore adapts a FileStore that cannot read byte ranges by always reading the entire file.
+type fullFileStore struct {
+	FileStore
 }
 
-func (s *s3store) FindFile(filepath string) (io.ReadCloser, error) {
-	downloadSession, e := s.newSession()
+func (s fullFileStore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	reader, e := s.FindFile(name)
 
 	if e != nil {
 		return nil, e
 	}
 
-	downloader := s3manager.NewDownloader(downloadSession)
-	pr, pw := io.Pipe()
-
-	go func() {
-		buffer := make([]byte, 0, constants.MaxHTMLReportFileSize)
-		writer := aws.NewWriteAtBuffer(buffer)
-		_, e := downloader.Download(writer, &s3.GetObjectInput{
-			Bucket: aws.String(s.bucketName),
-			Key:    aws.String(filepath),
-		})
-
-		if e != nil {
-			log.Printf("unable to download from s3: %s", e)
-			pw.CloseWithError(e)
-			return
-		}
-
-		_, e = io.Copy(pw, bytes.NewBuffer(writer.Bytes()))
-		pw.CloseWithError(e)
-	}()
-
-	return pr, nil
+	return &FileRange{ReadCloser: reader}, nil
 }
 
-func (s *s3store) newSession() (*session.Session, error) {
-	creds := credentials.NewStaticCredentials(s.accessID, s.accessKey, s.accessToken)
-
-	if _, e := creds.Get(); e != nil {
-		return nil, e
-	}
-
-	region := s.region
-
-	if region == "" {
-		region = "us-east-1"
+// NewFileStore returns an implementation of the FileStore interface for the driver (s3, local or memory).
+func NewFileStore(driver string, configuration *url.Values, db *sql.DB) (FileStore, error) {
+	switch driver {
+	case "s3":
+		store := &s3store{
+			accessID:    configuration.Get(constants.AWSAccessKeyIDEnvVariable),
+			accessToken: configuration.Get(constants.AWSAccessTokenEnvVariable),
+			accessKey:   configuration.Get(constants.AWSAccessKeyEnvVariable),
+			bucketName:  configuration.Get(constants.AWSBucketNameEnvVariable),
+			persistence: models.NewFileStore(db),
+			region:      configuration.Get(constants.AWSRegionEnvVariable),
+			endpoint:    configuration.Get(constants.AWSEndpointEnvVariable),
+			caBundle:    configuration.Get(constants.AWSCABundleEnvVariable),
+			keyPrefix:   configuration.Get(constants.AWSKeyPrefixEnvVariable),
+			compression: configuration.Get(constants.FileStoreCompressionEnvVariable),
+		}
+		store.presignExpiry, _ = time.ParseDuration(configuration.Get(constants.AWSPresignExpiryEnvVariable))
+		store.pathStyle, _ = strconv.ParseBool(configuration.Get(constants.AWSForcePathStyleEnvVariable))
+		store.skipVerify, _ = strconv.ParseBool(configuration.Get(constants.AWSSkipVerifyEnvVariable))
+		return store, nil
+	case "local":
+		store := &localstore{
+			root:        configuration.Get(constants.FileStoreDirectoryEnvVariable),
+			compression: configuration.Get(constants.FileStoreCompressionEnvVariable),
+			persistence: models.NewFileStore(db),
+		}
+		return store, nil
+	case "memory":
+		maxBytes, _ := strconv.ParseInt(configuration.Get(constants.MemoryStoreMaxBytesEnvVariable), 10, 64)
+		maxFiles, _ := strconv.Atoi(configuration.Get(constants.MemoryStoreMaxFilesEnvVariable))
+		store := newMemoryStore(maxBytes, maxFiles)
+		store.compression = configuration.Get(constants.FileStoreCompressionEnvVariable)
+		store.persistence = models.NewFileStore(db)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("invalid-file-store: %s", driver)
 	}
-
-	config := aws.NewConfig().WithRegion(region).WithCredentials(creds)
-
-	return session.NewSessionWithOptions(session.Options{Config: *config})
 }
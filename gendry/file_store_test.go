@@ -1,6 +1,38 @@
 package gendry
 
+import "net/url"
 import "testing"
+import "github.com/franela/goblin"
 
 func Test_FileStore(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("NewFileStore", func() {
+		g.It("returns a local store for the local driver", func() {
+			store, e := NewFileStore("local", &url.Values{}, nil)
+			g.Assert(e).Equal(nil)
+			_, ok := store.(*localstore)
+			g.Assert(ok).Equal(true)
+		})
+
+		g.It("returns an error for unknown drivers", func() {
+			_, e := NewFileStore("memroy", &url.Values{}, nil)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("configures the memory store limits", func() {
+			config := &url.Values{"MEMORY_STORE_MAX_BYTES": []string{"10"}, "MEMORY_STORE_MAX_FILES": []string{"2"}}
+			fs, _ := NewFileStore("memory", config, nil)
+			store := fs.(*memorystore)
+			g.Assert(store.maxBytes).Equal(int64(10))
+			g.Assert(store.maxFiles).Equal(2)
+		})
+
+		g.It("records the files of the memory store with the configured compression", func() {
+			fs, _ := NewFileStore("memory", &url.Values{"FILE_STORE_COMPRESSION": []string{"gzip"}}, nil)
+			store := fs.(*memorystore)
+			g.Assert(store.persistence == nil).Equal(false)
+			g.Assert(store.compression).Equal("gzip")
+		})
+	})
 }
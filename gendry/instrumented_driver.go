@@ -0,0 +1,235 @@
+package gendry
+
+import "time"
+import "context"
+import "database/sql/driver"
+
+// InstrumentDriver returns a database driver recording the latency and errors of the statements executed through
+// connections opened by the driver; register it with database/sql and open the database using its name.
+func InstrumentDriver(inner driver.Driver, metrics *Metrics) driver.Driver {
+	instrumented := &instrumentedDriver{
+		Driver: inner,
+		durations: metrics.Histogram(
+			"gendry_db_query_duration_seconds", "Time spent executing database statements.", DurationBuckets,
+			"operation",
+		),
+		errors: metrics.Counter("gendry_db_errors_total", "Database statements that failed.", "operation"),
+	}
+
+	return instrumented
+}
+
+type instrumentedDriver struct {
+	driver.Driver
+	durations *Histogram
+	errors    *Counter
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, e := d.Driver.Open(name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return &instrumentedConn{Conn: conn, driver: d}, nil
+}
+
+// record observes the statement's latency; bad connections are retried by database/sql and are not counted as errors.
+func (d *instrumentedDriver) record(operation string, start time.Time, e error) {
+	d.durations.Since(start, operation)
+
+	if e != nil && e != driver.ErrBadConn {
+		d.errors.Inc(operation)
+	}
+}
+
+// instrumentedConn wraps every statement prepared on the connection, timing queries executed directly on the wrapped
+// connection when it supports them.
+type instrumentedConn struct {
+	driver.Conn
+	driver *instrumentedDriver
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var e error
+
+	start := time.Now()
+
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, e = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, e = c.Conn.Prepare(query)
+	}
+
+	c.driver.record("prepare", start, e)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return &instrumentedStmt{Stmt: stmt, driver: c.driver}, nil
+}
+
+// ExecContext executes the query on the wrapped connection without preparing it when supported; driver.ErrSkip makes
+// database/sql fall back to a prepared statement.
+func (c *instrumentedConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	var result driver.Result
+	var e error
+
+	start := time.Now()
+
+	switch executor := c.Conn.(type) {
+	case driver.ExecerContext:
+		result, e = executor.ExecContext(ctx, query, args)
+	case driver.Execer:
+		result, e = executor.Exec(query, namedValues(args))
+	default:
+		return nil, driver.ErrSkip
+	}
+
+	if e != driver.ErrSkip {
+		c.driver.record("exec", start, e)
+	}
+
+	return result, e
+}
+
+// QueryContext runs the query on the wrapped connection without preparing it when supported; driver.ErrSkip makes
+// database/sql fall back to a prepared statement.
+func (c *instrumentedConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	var rows driver.Rows
+	var e error
+
+	start := time.Now()
+
+	switch querier := c.Conn.(type) {
+	case driver.QueryerContext:
+		rows, e = querier.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		rows, e = querier.Query(query, namedValues(args))
+	default:
+		return nil, driver.ErrSkip
+	}
+
+	if e != driver.ErrSkip {
+		c.driver.record("query", start, e)
+	}
+
+	return rows, e
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, options driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, options)
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ResetSession keeps the session reset of the wrapped connection; connections without one are reused as is.
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid keeps the validation of the wrapped connection; connections without one are always considered valid.
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	driver *instrumentedDriver
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, e := s.Stmt.Exec(args)
+	s.driver.record("exec", start, e)
+	return result, e
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, e := s.Stmt.Query(args)
+	s.driver.record("query", start, e)
+	return rows, e
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	executor, ok := s.Stmt.(driver.StmtExecContext)
+
+	if !ok {
+		return s.Exec(namedValues(args))
+	}
+
+	start := time.Now()
+	result, e := executor.ExecContext(ctx, args)
+	s.driver.record("exec", start, e)
+	return result, e
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	querier, ok := s.Stmt.(driver.StmtQueryContext)
+
+	if !ok {
+		return s.Query(namedValues(args))
+	}
+
+	start := time.Now()
+	rows, e := querier.QueryContext(ctx, args)
+	s.driver.record("query", start, e)
+	return rows, e
+}
+
+// ColumnConverter keeps the argument conversion of the wrapped statement.
+func (s *instrumentedStmt) ColumnConverter(index int) driver.ValueConverter {
+	if converter, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return converter.ColumnConverter(index)
+	}
+
+	return driver.DefaultParameterConverter
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
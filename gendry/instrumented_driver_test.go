@@ -0,0 +1,134 @@
+package gendry
+
+import "io"
+import "testing"
+import "context"
+import "database/sql"
+import "database/sql/driver"
+import "github.com/franela/goblin"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+
+// testDriver opens connections whose statements succeed unless their query is "fail". Queries other than "prepare"
+// are executed directly on the connection, counting the statements that were prepared.
+type testDriver struct {
+	prepared *int
+}
+
+func (d testDriver) Open(string) (driver.Conn, error) {
+	return testConn{d.prepared}, nil
+}
+
+type testConn struct {
+	prepared *int
+}
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) {
+	*c.prepared++
+	return testStmt{query}, nil
+}
+
+func (c testConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	if query == "prepare" {
+		return nil, driver.ErrSkip
+	}
+
+	return testStmt{query}.Exec(args)
+}
+
+func (c testConn) ResetSession(context.Context) error {
+	return driver.ErrBadConn
+}
+
+func (c testConn) IsValid() bool {
+	return false
+}
+
+func (c testConn) Close() error {
+	return nil
+}
+
+func (c testConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type testStmt struct {
+	query string
+}
+
+func (s testStmt) Close() error {
+	return nil
+}
+
+func (s testStmt) NumInput() int {
+	return -1
+}
+
+func (s testStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.query == "fail" {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s testStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, io.ErrUnexpectedEOF
+}
+
+// testConnector opens connections of the driver, allowing databases to be opened without registering the driver.
+type testConnector struct {
+	driver driver.Driver
+}
+
+func (c testConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open("")
+}
+
+func (c testConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func Test_InstrumentedDriver(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("instrumentedDriver", func() {
+		var instrumented *instrumentedDriver
+		var db *sql.DB
+		var prepared int
+
+		g.BeforeEach(func() {
+			prepared = 0
+			instrumented = InstrumentDriver(testDriver{&prepared}, NewMetrics()).(*instrumentedDriver)
+			db = sql.OpenDB(testConnector{instrumented})
+		})
+
+		g.AfterEach(func() {
+			db.Close()
+		})
+
+		g.It("records the latency and failures of statements executed on the connection", func() {
+			_, e := db.Exec("insert", 1)
+			g.Assert(e).Equal(nil)
+			_, e = db.Exec("fail")
+			g.Assert(e == nil).Equal(false)
+
+			g.Assert(prepared).Equal(0)
+			g.Assert(testutil.CollectAndCount(instrumented.durations.vec)).Equal(1)
+			g.Assert(testutil.ToFloat64(instrumented.errors.vec.WithLabelValues("exec"))).Equal(float64(1))
+		})
+
+		g.It("times prepared statements when the connection skips direct execution", func() {
+			_, e := db.Exec("prepare", 1)
+			g.Assert(e).Equal(nil)
+			g.Assert(prepared).Equal(1)
+			g.Assert(testutil.CollectAndCount(instrumented.durations.vec)).Equal(2)
+		})
+
+		g.It("passes session resets and validation through to the connection", func() {
+			conn, e := instrumented.Open("")
+			g.Assert(e).Equal(nil)
+			g.Assert(conn.(driver.SessionResetter).ResetSession(context.Background())).Equal(driver.ErrBadConn)
+			g.Assert(conn.(driver.Validator).IsValid()).Equal(false)
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package gendry
+
+import "io"
+import "time"
+
+// InstrumentFileStore returns a file store recording the latency and errors of every operation of the store, labeled
+// by its driver. The returned store implements each of the optional file store interfaces, behaving as callers of a
+// store without that capability would: ranges are read in full, urls are never signed and no files are listed.
+func InstrumentFileStore(store FileStore, metrics *Metrics) FileStore {
+	instrumented := &instrumentedFileStore{
+		FileStore: store,
+		driver:    fileStoreDriver(store),
+		durations: metrics.Histogram(
+			"gendry_file_store_operation_duration_seconds", "Time spent in file store operations.", DurationBuckets,
+			"driver", "operation",
+		),
+		errors: metrics.Counter(
+			"gendry_file_store_errors_total", "File store operations that failed.", "driver", "operation",
+		),
+	}
+
+	return instrumented
+}
+
+type instrumentedFileStore struct {
+	FileStore
+	driver    string
+	durations *Histogram
+	errors    *Counter
+}
+
+// instrumentedFile records the time spent closing (i.e. persisting) a new file.
+type instrumentedFile struct {
+	io.WriteCloser
+	store *instrumentedFileStore
+}
+
+func (f *instrumentedFile) Close() error {
+	start := time.Now()
+	e := f.WriteCloser.Close()
+	f.store.record("close", start, e)
+	return e
+}
+
+func (s *instrumentedFileStore) NewFile(contentType string, directory string) (string, io.WriteCloser, error) {
+	start := time.Now()
+	id, file, e := s.FileStore.NewFile(contentType, directory)
+	s.record("new", start, e)
+
+	if e != nil {
+		return id, file, e
+	}
+
+	return id, &instrumentedFile{WriteCloser: file, store: s}, nil
+}
+
+func (s *instrumentedFileStore) FindFile(name string) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, e := s.FileStore.FindFile(name)
+	s.record("find", start, e)
+	return reader, e
+}
+
+func (s *instrumentedFileStore) DeleteFile(name string) error {
+	start := time.Now()
+	e := s.FileStore.DeleteFile(name)
+	s.record("delete", start, e)
+	return e
+}
+
+func (s *instrumentedFileStore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	ranged, ok := s.FileStore.(RangeFileStore)
+
+	if !ok {
+		ranged = fullFileStore{s.FileStore}
+	}
+
+	start := time.Now()
+	file, e := ranged.FindFileRange(name, byteRange)
+	s.record("find_range", start, e)
+	return file, e
+}
+
+func (s *instrumentedFileStore) SignFile(name string, encoded bool) (string, error) {
+	signer, ok := s.FileStore.(SigningFileStore)
+
+	if !ok {
+		return "", ErrUnsignable
+	}
+
+	start := time.Now()
+	location, e := signer.SignFile(name, encoded)
+	s.record("sign", start, e)
+	return location, e
+}
+
+func (s *instrumentedFileStore) ListFiles(directory string) ([]StoredFile, error) {
+	lister, ok := s.FileStore.(FileLister)
+
+	if !ok {
+		return nil, nil
+	}
+
+	start := time.Now()
+	files, e := lister.ListFiles(directory)
+	s.record("list", start, e)
+	return files, e
+}
+
+// record observes the operation's latency; unsatisfiable ranges and unsignable files are expected and not errors.
+func (s *instrumentedFileStore) record(operation string, start time.Time, e error) {
+	s.durations.Since(start, s.driver, operation)
+
+	if e != nil && e != ErrInvalidRange && e != ErrUnsignable {
+		s.errors.Inc(s.driver, operation)
+	}
+}
+
+// fileStoreDriver returns the name of the driver the store was created for by NewFileStore.
+func fileStoreDriver(store FileStore) string {
+	switch store.(type) {
+	case *s3store:
+		return "s3"
+	case *localstore:
+		return "local"
+	case *memorystore:
+		return "memory"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,56 @@
+package gendry
+
+import "io"
+import "testing"
+import "github.com/franela/goblin"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+
+func Test_InstrumentedFileStore(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("instrumentedFileStore", func() {
+		var store FileStore
+		var instrumented *instrumentedFileStore
+
+		g.BeforeEach(func() {
+			store = InstrumentFileStore(newMemoryStore(0, 0), NewMetrics())
+			instrumented = store.(*instrumentedFileStore)
+		})
+
+		failures := func(operation string) float64 {
+			return testutil.ToFloat64(instrumented.errors.vec.WithLabelValues("memory", operation))
+		}
+
+		g.It("records the latency of operations by driver", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "<html></html>")
+			writer.Close()
+			store.FindFile("reports/" + id)
+
+			g.Assert(instrumented.driver).Equal("memory")
+			g.Assert(testutil.CollectAndCount(instrumented.durations.vec)).Equal(3)
+		})
+
+		g.It("counts failed operations", func() {
+			store.FindFile("reports/missing")
+			g.Assert(failures("find")).Equal(float64(1))
+		})
+
+		g.It("reads files in full for stores that can not read ranges", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "<html></html>")
+			writer.Close()
+
+			file, e := store.(RangeFileStore).FindFileRange("reports/"+id, "bytes=0-1")
+			g.Assert(e).Equal(nil)
+			defer file.Close()
+			g.Assert(file.ContentRange).Equal("")
+		})
+
+		g.It("does not sign urls for stores that can not sign them", func() {
+			_, e := store.(SigningFileStore).SignFile("reports/missing", false)
+			g.Assert(e).Equal(ErrUnsignable)
+			g.Assert(testutil.CollectAndCount(instrumented.errors.vec)).Equal(0)
+		})
+	})
+}
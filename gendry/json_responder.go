@@ -8,8 +8,22 @@ type jsonResponder struct {
 }
 
 func (r jsonResponder) renderSuccess(writer http.ResponseWriter, data ...interface{}) {
+	r.render(writer, 200, nil, data...)
+}
+
+func (r jsonResponder) renderError(writer http.ResponseWriter, errors ...string) {
+	r.render(writer, 422, errors)
+}
+
+// renderFailure is used when a request was valid but its outcome was negative (e.g a failed quality gate); the data is
+// rendered with the errors using a 409 so clients can tell failures apart from invalid requests.
+func (r jsonResponder) renderFailure(writer http.ResponseWriter, errors []string, data ...interface{}) {
+	r.render(writer, 409, errors, data...)
+}
+
+func (r jsonResponder) render(writer http.ResponseWriter, status int, errors []string, data ...interface{}) {
 	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(200)
+	writer.WriteHeader(status)
 	meta := make(map[string]interface{})
 	meta["time"] = time.Now()
 
@@ -28,28 +42,15 @@ func (r jsonResponder) renderSuccess(writer http.ResponseWriter, data ...interfa
 		results = append(results, item)
 	}
 
-	response := struct {
-		Metadata map[string]interface{} `json:"meta"`
-		Errors   []string               `json:"errors"`
-		Results  []interface{}          `json:"data"`
-	}{meta, nil, results}
-
-	encoder := json.NewEncoder(writer)
-	encoder.Encode(&response)
-}
-
-func (r jsonResponder) renderError(writer http.ResponseWriter, errors ...string) {
-	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(422)
-	meta := make(map[string]interface{})
-
-	meta["time"] = time.Now()
+	if len(results) == 0 && errors != nil {
+		results = nil
+	}
 
 	response := struct {
 		Metadata map[string]interface{} `json:"meta"`
 		Errors   []string               `json:"errors"`
 		Results  []interface{}          `json:"data"`
-	}{meta, errors, nil}
+	}{meta, errors, results}
 
 	encoder := json.NewEncoder(writer)
 	encoder.Encode(&response)
@@ -72,5 +72,23 @@ func Test_jsonResponder(t *testing.T) {
 			g.Assert(e).Equal(nil)
 			g.Assert(expected.Errors[0]).Equal("bad-request")
 		})
+
+		g.It("renders failures with both the errors and data", func() {
+			r.renderFailure(o, []string{"too-low"}, struct {
+				Name string `json:"name"`
+			}{"danny"})
+			g.Assert(o.Code).Equal(409)
+			decoder := json.NewDecoder(o.Body)
+			expected := struct {
+				Errors []string `json:"errors"`
+				Data   []struct {
+					Name string `json:"name"`
+				} `json:"data"`
+			}{}
+			e := decoder.Decode(&expected)
+			g.Assert(e).Equal(nil)
+			g.Assert(expected.Errors[0]).Equal("too-low")
+			g.Assert(expected.Data[0].Name).Equal("danny")
+		})
 	})
 }
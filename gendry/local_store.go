@@ -0,0 +1,248 @@
+package gendry
+
+import "os"
+import "io"
+import "fmt"
+import "time"
+import "strings"
+import "path/filepath"
+import "github.com/satori/go.uuid"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// localstore persists files onto the local filesystem beneath a root directory.
+type localstore struct {
+	root        string
+	compression string
+	persistence models.FileStore
+}
+
+// localFile is the writer returned by the local store; content is written into a temporary file that is renamed into
+// its final location when closed so readers never observe partially written files.
+type localFile struct {
+	*encodedWriter
+	file        *os.File
+	id          string
+	encoding    string
+	destination string
+	persistence models.FileStore
+}
+
+func (f *localFile) Close() error {
+	if e := f.encodedWriter.Close(); e != nil {
+		f.file.Close()
+		os.Remove(f.file.Name())
+		return e
+	}
+
+	if e := f.file.Sync(); e != nil {
+		f.file.Close()
+		os.Remove(f.file.Name())
+		return e
+	}
+
+	if e := f.file.Close(); e != nil {
+		os.Remove(f.file.Name())
+		return e
+	}
+
+	object, e := recordContent(f.persistence, f.id, f.encoding, f.checksum)
+
+	if e != nil {
+		os.Remove(f.file.Name())
+		return e
+	}
+
+	// Identical content is already stored; the record now references that content so this copy is discarded.
+	if object != f.id {
+		os.Remove(f.file.Name())
+		return validateFile(f.persistence, f.id)
+	}
+
+	if e := os.Rename(f.file.Name(), f.destination); e != nil {
+		os.Remove(f.file.Name())
+		return e
+	}
+
+	return validateFile(f.persistence, f.id)
+}
+
+func (s *localstore) NewFile(contentType string, directory string) (string, io.WriteCloser, error) {
+	target, e := s.resolve(directory)
+
+	if e != nil {
+		return "", nil, e
+	}
+
+	if e := os.MkdirAll(target, 0755); e != nil {
+		return "", nil, e
+	}
+
+	id := fmt.Sprintf("%s", uuid.NewV4())
+	temp, e := os.OpenFile(filepath.Join(target, fmt.Sprintf(".%s.tmp", id)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+	if e != nil {
+		return "", nil, e
+	}
+
+	record := models.File{
+		SystemID:    id,
+		Status:      "PENDING",
+		CreatedAt:   time.Now(),
+		ContentType: contentType,
+		ObjectID:    id,
+		Encoding:    storedEncoding(s.compression),
+	}
+
+	if _, e := s.persistence.CreateFiles(record); e != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return "", nil, e
+	}
+
+	file := &localFile{
+		encodedWriter: newEncodedWriter(temp, record.Encoding),
+		file:          temp,
+		id:            id,
+		encoding:      record.Encoding,
+		destination:   filepath.Join(target, id),
+		persistence:   s.persistence,
+	}
+
+	return id, file, nil
+}
+
+// FindFile returns the original content of the file, verified against its checksum.
+func (s *localstore) FindFile(name string) (io.ReadCloser, error) {
+	file, record, e := s.open(name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if record == nil {
+		return file, nil
+	}
+
+	decoded, e := decodeFile(file, record.Encoding)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return verifyFile(decoded, record), nil
+}
+
+// FindFileRange returns the file as stored (i.e. possibly encoded); the file is seekable so ranges are left to the
+// caller.
+func (s *localstore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	file, record, e := s.open(name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if record == nil || record.Encoding == "" {
+		return &FileRange{ReadCloser: verifyFile(file, record)}, nil
+	}
+
+	return &FileRange{ReadCloser: file, Encoding: record.Encoding}, nil
+}
+
+// open opens the object holding the file's content, returning it along with the file's record (if it has one).
+func (s *localstore) open(name string) (*os.File, *models.File, error) {
+	object, record := objectName(s.persistence, name)
+	location, e := s.resolve(object)
+
+	if e != nil {
+		return nil, nil, e
+	}
+
+	file, e := os.Open(location)
+
+	if e != nil {
+		return nil, nil, e
+	}
+
+	return file, record, nil
+}
+
+// DeleteFile removes the file's record, removing the file from disk once no other record references its content;
+// missing files are not an error.
+func (s *localstore) DeleteFile(name string) error {
+	if filepath.Clean("/"+name) == "/" {
+		return fmt.Errorf("invalid-path: %s", name)
+	}
+
+	object, unreferenced, e := releaseFile(s.persistence, name)
+
+	if e != nil || unreferenced != true {
+		return e
+	}
+
+	location, e := s.resolve(object)
+
+	if e != nil {
+		return e
+	}
+
+	if e := os.Remove(location); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+
+	return nil
+}
+
+// ListFiles walks the directory, including temporary files left behind by interrupted writes.
+func (s *localstore) ListFiles(directory string) ([]StoredFile, error) {
+	root, e := s.resolve("")
+
+	if e != nil {
+		return nil, e
+	}
+
+	target, e := s.resolve(directory)
+
+	if e != nil {
+		return nil, e
+	}
+
+	results := make([]StoredFile, 0)
+
+	walker := func(location string, info os.FileInfo, e error) error {
+		if e != nil || info.IsDir() {
+			return e
+		}
+
+		name, e := filepath.Rel(root, location)
+
+		if e != nil {
+			return e
+		}
+
+		results = append(results, StoredFile{Name: filepath.ToSlash(name), ModifiedAt: info.ModTime()})
+		return nil
+	}
+
+	if e := filepath.Walk(target, walker); e != nil && !os.IsNotExist(e) {
+		return nil, e
+	}
+
+	return results, nil
+}
+
+// resolve returns the absolute location of the path within the root, refusing paths that would escape it.
+func (s *localstore) resolve(name string) (string, error) {
+	root, e := filepath.Abs(s.root)
+
+	if e != nil {
+		return "", e
+	}
+
+	location := filepath.Join(root, filepath.FromSlash(filepath.Clean("/"+name)))
+
+	if location != root && strings.HasPrefix(location, root+string(filepath.Separator)) != true {
+		return "", fmt.Errorf("invalid-path: %s", name)
+	}
+
+	return location, nil
+}
@@ -0,0 +1,240 @@
+package gendry
+
+import "os"
+import "io"
+import "bytes"
+import "testing"
+import "io/ioutil"
+import "path/filepath"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// testReleasingPersistence deletes every valid file matching the lookups of valid files, as if they were released
+// concurrently with the lookup.
+type testReleasingPersistence struct {
+	*testFilePersistence
+}
+
+func (p *testReleasingPersistence) FindFiles(bp *models.FileBlueprint) ([]*models.File, error) {
+	results, e := p.testFilePersistence.FindFiles(bp)
+
+	if len(bp.Status) == 0 || bp.Status[0] != "VALID" {
+		return results, e
+	}
+
+	unlimited := *bp
+	unlimited.Limit = 0
+	released, _ := p.testFilePersistence.FindFiles(&unlimited)
+
+	for _, f := range released {
+		p.testFilePersistence.DeleteFiles(&models.FileBlueprint{SystemID: []string{f.SystemID}})
+	}
+
+	return results, e
+}
+
+func Test_LocalStore(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("localstore", func() {
+		var store *localstore
+		var persistence *testFilePersistence
+		var root string
+
+		g.BeforeEach(func() {
+			root, _ = ioutil.TempDir("", "gendry-local-store")
+			persistence = &testFilePersistence{files: make(map[string]*models.File)}
+			store = &localstore{root: root, persistence: persistence}
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(root)
+		})
+
+		g.It("creates a pending file record for new files", func() {
+			id, writer, e := store.NewFile("text/html", "reports")
+			g.Assert(e).Equal(nil)
+			defer writer.Close()
+			g.Assert(persistence.files[id].Status).Equal("PENDING")
+		})
+
+		g.It("does not expose the file until it has been closed", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "<html></html>")
+			_, e := store.FindFile(filepath.Join("reports", id))
+			g.Assert(e == nil).Equal(false)
+			writer.Close()
+		})
+
+		g.It("moves the file into place and marks it valid when closed", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "<html></html>")
+			g.Assert(writer.Close()).Equal(nil)
+			g.Assert(persistence.files[id].Status).Equal("VALID")
+			g.Assert(persistence.files[id].Size).Equal(int64(13))
+			g.Assert(persistence.files[id].ContentType).Equal("text/html")
+			g.Assert(len(persistence.files[id].Checksum)).Equal(64)
+
+			reader, e := store.FindFile(filepath.Join("reports", id))
+			g.Assert(e).Equal(nil)
+			defer reader.Close()
+			output := new(bytes.Buffer)
+			io.Copy(output, reader)
+			g.Assert(output.String()).Equal("<html></html>")
+		})
+
+		g.It("keeps lookups within the root directory", func() {
+			location, e := store.resolve("../../etc/passwd")
+			g.Assert(e).Equal(nil)
+			g.Assert(location).Equal(filepath.Join(root, "etc", "passwd"))
+		})
+
+		g.It("removes deleted files from disk along with their records", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			writer.Close()
+			g.Assert(store.DeleteFile(filepath.Join("reports", id))).Equal(nil)
+			_, e := os.Stat(filepath.Join(root, "reports", id))
+			g.Assert(os.IsNotExist(e)).Equal(true)
+			g.Assert(persistence.files[id] == nil).Equal(true)
+		})
+
+		g.It("does not return an error when deleting missing files", func() {
+			g.Assert(store.DeleteFile("reports/missing")).Equal(nil)
+		})
+
+		g.It("refuses to delete the root directory", func() {
+			g.Assert(store.DeleteFile("/../..") == nil).Equal(false)
+			_, e := os.Stat(root)
+			g.Assert(e).Equal(nil)
+		})
+
+		g.It("lists the files beneath a directory, including temporary files", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			writer.Close()
+			_, pending, _ := store.NewFile("text/html", "reports")
+			defer pending.Close()
+
+			files, e := store.ListFiles("reports")
+			g.Assert(e).Equal(nil)
+			g.Assert(len(files)).Equal(2)
+
+			names := map[string]bool{files[0].Name: true, files[1].Name: true}
+			g.Assert(names["reports/"+id]).Equal(true)
+		})
+
+		g.It("lists no files for missing directories", func() {
+			files, e := store.ListFiles("missing")
+			g.Assert(e).Equal(nil)
+			g.Assert(len(files)).Equal(0)
+		})
+
+		g.It("detects files modified after they were written", func() {
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "<html></html>")
+			writer.Close()
+			ioutil.WriteFile(filepath.Join(root, "reports", id), []byte("<html>!</html>"), 0644)
+
+			reader, e := store.FindFile(filepath.Join("reports", id))
+			g.Assert(e).Equal(nil)
+			defer reader.Close()
+			_, e = ioutil.ReadAll(reader)
+			g.Assert(e).Equal(ErrChecksumMismatch)
+		})
+
+		g.Describe("with identical content", func() {
+			var first, second string
+
+			g.BeforeEach(func() {
+				for _, id := range []*string{&first, &second} {
+					var writer io.WriteCloser
+					*id, writer, _ = store.NewFile("text/html", "reports")
+					io.WriteString(writer, "<html></html>")
+					writer.Close()
+				}
+			})
+
+			g.It("stores the content once", func() {
+				files, _ := store.ListFiles("reports")
+				g.Assert(len(files)).Equal(1)
+				g.Assert(persistence.files[second].ObjectID).Equal(first)
+				g.Assert(persistence.files[second].Status).Equal("VALID")
+
+				reader, e := store.FindFile(filepath.Join("reports", second))
+				g.Assert(e).Equal(nil)
+				defer reader.Close()
+				content, e := ioutil.ReadAll(reader)
+				g.Assert(e).Equal(nil)
+				g.Assert(string(content)).Equal("<html></html>")
+			})
+
+			g.It("keeps the content until every file referencing it has been deleted", func() {
+				g.Assert(store.DeleteFile(filepath.Join("reports", first))).Equal(nil)
+				_, e := store.FindFile(filepath.Join("reports", second))
+				g.Assert(e).Equal(nil)
+
+				g.Assert(store.DeleteFile(filepath.Join("reports", second))).Equal(nil)
+				files, _ := store.ListFiles("reports")
+				g.Assert(len(files)).Equal(0)
+				g.Assert(len(persistence.files)).Equal(0)
+			})
+
+			g.It("keeps shared content when the owner of the content is deleted again", func() {
+				g.Assert(store.DeleteFile(filepath.Join("reports", first))).Equal(nil)
+				g.Assert(store.DeleteFile(filepath.Join("reports", first))).Equal(nil)
+
+				reader, e := store.FindFile(filepath.Join("reports", second))
+				g.Assert(e).Equal(nil)
+				reader.Close()
+			})
+
+			g.It("stores its own copy when the shared content is released while being linked", func() {
+				store.persistence = &testReleasingPersistence{testFilePersistence: persistence}
+
+				id, writer, _ := store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html></html>")
+				g.Assert(writer.Close()).Equal(nil)
+				g.Assert(persistence.files[id].ObjectID).Equal(id)
+
+				reader, e := store.FindFile(filepath.Join("reports", id))
+				g.Assert(e).Equal(nil)
+				reader.Close()
+			})
+		})
+
+		g.Describe("with gzip compression", func() {
+			var id string
+
+			g.BeforeEach(func() {
+				var writer io.WriteCloser
+				store.compression = "gzip"
+				id, writer, _ = store.NewFile("text/html", "reports")
+				io.WriteString(writer, "<html></html>")
+				writer.Close()
+			})
+
+			g.It("stores the file compressed", func() {
+				g.Assert(persistence.files[id].Encoding).Equal("gzip")
+				g.Assert(persistence.files[id].Size).Equal(int64(13))
+
+				stored, _ := ioutil.ReadFile(filepath.Join(root, "reports", id))
+				g.Assert(bytes.HasPrefix(stored, []byte{0x1f, 0x8b})).Equal(true)
+			})
+
+			g.It("returns the original content when finding the file", func() {
+				reader, e := store.FindFile(filepath.Join("reports", id))
+				g.Assert(e).Equal(nil)
+				defer reader.Close()
+				content, e := ioutil.ReadAll(reader)
+				g.Assert(e).Equal(nil)
+				g.Assert(string(content)).Equal("<html></html>")
+			})
+
+			g.It("returns the compressed content along with its encoding when finding the range", func() {
+				file, e := store.FindFileRange(filepath.Join("reports", id), "")
+				g.Assert(e).Equal(nil)
+				defer file.Close()
+				g.Assert(file.Encoding).Equal("gzip")
+			})
+		})
+	})
+}
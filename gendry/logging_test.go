@@ -0,0 +1,17 @@
+package gendry
+
+import "fmt"
+
+// testLogger is a LeveledLogger recording every formatted message, regardless of level.
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Infof(format string, args ...interface{})  { l.record(format, args) }
+func (l *testLogger) Debugf(format string, args ...interface{}) { l.record(format, args) }
+func (l *testLogger) Warnf(format string, args ...interface{})  { l.record(format, args) }
+func (l *testLogger) Errorf(format string, args ...interface{}) { l.record(format, args) }
+
+func (l *testLogger) record(format string, args []interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,256 @@
+package gendry
+
+import "io"
+import "fmt"
+import "sync"
+import "path"
+import "time"
+import "bytes"
+import "container/list"
+import "github.com/satori/go.uuid"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// memorystore keeps files in memory, evicting the least recently used files once either of its (optional) limits on
+// total size or file count have been exceeded. It is safe for concurrent use. When given a persistence, files are
+// recorded, checksummed, encoded and shared by content exactly as they are by the local and s3 stores; without one
+// files are kept as-is.
+type memorystore struct {
+	sync.Mutex
+	maxBytes    int64
+	maxFiles    int
+	size        int64
+	files       map[string]*list.Element
+	recent      *list.List
+	compression string
+	persistence models.FileStore
+}
+
+type memoryEntry struct {
+	key         string
+	contentType string
+	content     []byte
+}
+
+// memoryFile buffers writes until closed, at which point the content is added to the store.
+type memoryFile struct {
+	*encodedWriter
+	content     *bytes.Buffer
+	id          string
+	directory   string
+	encoding    string
+	contentType string
+	store       *memorystore
+	closed      bool
+}
+
+func (f *memoryFile) Close() error {
+	if f.closed {
+		return fmt.Errorf("file-closed")
+	}
+
+	f.closed = true
+
+	if e := f.encodedWriter.Close(); e != nil {
+		return e
+	}
+
+	persistence := f.store.persistence
+	entry := &memoryEntry{key: path.Join(f.directory, f.id), contentType: f.contentType, content: f.content.Bytes()}
+
+	if persistence == nil {
+		return f.store.put(entry)
+	}
+
+	object, e := recordContent(persistence, f.id, f.encoding, f.checksum)
+
+	if e != nil {
+		return e
+	}
+
+	// Identical content is already stored; the record now references that content so this copy is discarded.
+	if object != f.id {
+		return validateFile(persistence, f.id)
+	}
+
+	if e := f.store.put(entry); e != nil {
+		return e
+	}
+
+	return validateFile(persistence, f.id)
+}
+
+// memoryReader is a seekable reader of a file's content, allowing range requests to be served.
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (r memoryReader) Close() error {
+	return nil
+}
+
+// newMemoryStore returns an empty memory store; a limit of zero disables that limit.
+func newMemoryStore(maxBytes int64, maxFiles int) *memorystore {
+	return &memorystore{
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		files:    make(map[string]*list.Element),
+		recent:   list.New(),
+	}
+}
+
+func (s *memorystore) NewFile(contentType string, directory string) (string, io.WriteCloser, error) {
+	id := fmt.Sprintf("%s", uuid.NewV4())
+	content := new(bytes.Buffer)
+	file := &memoryFile{
+		content:     content,
+		id:          id,
+		directory:   directory,
+		contentType: contentType,
+		store:       s,
+	}
+
+	if s.persistence != nil {
+		record := models.File{
+			SystemID:    id,
+			Status:      "PENDING",
+			CreatedAt:   time.Now(),
+			ContentType: contentType,
+			ObjectID:    id,
+			Encoding:    storedEncoding(s.compression),
+		}
+
+		if _, e := s.persistence.CreateFiles(record); e != nil {
+			return "", nil, e
+		}
+
+		file.encoding = record.Encoding
+	}
+
+	file.encodedWriter = newEncodedWriter(content, file.encoding)
+	return id, file, nil
+}
+
+// FindFile returns the original content of the file, verified against its checksum when it has a record.
+func (s *memorystore) FindFile(name string) (io.ReadCloser, error) {
+	reader, record, e := s.open(name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if record == nil {
+		return reader, nil
+	}
+
+	decoded, e := decodeFile(reader, record.Encoding)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return verifyFile(decoded, record), nil
+}
+
+// FindFileRange returns the file as stored (i.e. possibly encoded); the reader is seekable so ranges are left to the
+// caller.
+func (s *memorystore) FindFileRange(name string, byteRange string) (*FileRange, error) {
+	reader, record, e := s.open(name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if record == nil || record.Encoding == "" {
+		return &FileRange{ReadCloser: verifyFile(reader, record)}, nil
+	}
+
+	return &FileRange{ReadCloser: reader, Encoding: record.Encoding}, nil
+}
+
+// open returns a reader of the entry holding the file's content, along with the file's record (if it has one).
+func (s *memorystore) open(name string) (io.ReadCloser, *models.File, error) {
+	var record *models.File
+	object := path.Clean(name)
+
+	if s.persistence != nil {
+		object, record = objectName(s.persistence, path.Clean(name))
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	element, ok := s.files[object]
+
+	if !ok {
+		return nil, nil, fmt.Errorf("not-found")
+	}
+
+	s.recent.MoveToFront(element)
+	entry := element.Value.(*memoryEntry)
+
+	return memoryReader{bytes.NewReader(entry.content)}, record, nil
+}
+
+// DeleteFile removes the file's record (if the store has a persistence), removing its content once no other record
+// references it; missing files are not an error.
+func (s *memorystore) DeleteFile(name string) error {
+	object := path.Clean(name)
+
+	if s.persistence != nil {
+		released, unreferenced, e := releaseFile(s.persistence, object)
+
+		if e != nil || unreferenced != true {
+			return e
+		}
+
+		object = released
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if element, ok := s.files[object]; ok {
+		s.remove(element)
+	}
+
+	return nil
+}
+
+func (s *memorystore) put(entry *memoryEntry) error {
+	size := int64(len(entry.content))
+
+	if s.maxBytes > 0 && size > s.maxBytes {
+		return fmt.Errorf("file-too-large: %d bytes (max %d)", size, s.maxBytes)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if existing, ok := s.files[entry.key]; ok {
+		s.remove(existing)
+	}
+
+	s.files[entry.key] = s.recent.PushFront(entry)
+	s.size += size
+
+	for s.overLimit() {
+		s.remove(s.recent.Back())
+	}
+
+	return nil
+}
+
+func (s *memorystore) overLimit() bool {
+	if s.maxBytes > 0 && s.size > s.maxBytes {
+		return true
+	}
+
+	return s.maxFiles > 0 && s.recent.Len() > s.maxFiles
+}
+
+// remove drops the element from the store; callers must hold the lock.
+func (s *memorystore) remove(element *list.Element) {
+	entry := s.recent.Remove(element).(*memoryEntry)
+	delete(s.files, entry.key)
+	s.size -= int64(len(entry.content))
+}
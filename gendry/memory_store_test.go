@@ -0,0 +1,188 @@
+package gendry
+
+import "io"
+import "sync"
+import "path"
+import "bytes"
+import "testing"
+import "io/ioutil"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_MemoryStore(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	write := func(store *memorystore, content string) string {
+		id, writer, _ := store.NewFile("text/html", "reports")
+		io.WriteString(writer, content)
+		writer.Close()
+		return path.Join("reports", id)
+	}
+
+	read := func(store *memorystore, key string) (string, error) {
+		reader, e := store.FindFile(key)
+
+		if e != nil {
+			return "", e
+		}
+
+		defer reader.Close()
+		output := new(bytes.Buffer)
+		io.Copy(output, reader)
+		return output.String(), nil
+	}
+
+	g.Describe("memorystore", func() {
+		g.It("returns an error for files that do not exist", func() {
+			_, e := newMemoryStore(0, 0).FindFile("reports/missing")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("does not expose files until they have been closed", func() {
+			store := newMemoryStore(0, 0)
+			id, writer, _ := store.NewFile("text/html", "reports")
+			io.WriteString(writer, "hello")
+			_, e := store.FindFile(path.Join("reports", id))
+			g.Assert(e == nil).Equal(false)
+			writer.Close()
+			content, e := read(store, path.Join("reports", id))
+			g.Assert(e).Equal(nil)
+			g.Assert(content).Equal("hello")
+		})
+
+		g.It("returns an error when closing a file twice", func() {
+			_, writer, _ := newMemoryStore(0, 0).NewFile("text/html", "reports")
+			g.Assert(writer.Close()).Equal(nil)
+			g.Assert(writer.Close() == nil).Equal(false)
+		})
+
+		g.It("rejects files larger than the size limit", func() {
+			_, writer, _ := newMemoryStore(3, 0).NewFile("text/html", "reports")
+			io.WriteString(writer, "hello")
+			g.Assert(writer.Close() == nil).Equal(false)
+		})
+
+		g.It("evicts the least recently used file once the file limit is exceeded", func() {
+			store := newMemoryStore(0, 2)
+			first, second := write(store, "first"), write(store, "second")
+			read(store, first)
+			third := write(store, "third")
+
+			_, e := read(store, second)
+			g.Assert(e == nil).Equal(false)
+			_, e = read(store, first)
+			g.Assert(e).Equal(nil)
+			_, e = read(store, third)
+			g.Assert(e).Equal(nil)
+		})
+
+		g.It("evicts files once the size limit is exceeded", func() {
+			store := newMemoryStore(10, 0)
+			first := write(store, "12345")
+			write(store, "12345")
+			write(store, "12345")
+			_, e := read(store, first)
+			g.Assert(e == nil).Equal(false)
+			g.Assert(store.size).Equal(int64(10))
+		})
+
+		g.It("is safe for concurrent use", func() {
+			store := newMemoryStore(0, 5)
+			wg := sync.WaitGroup{}
+
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+					read(store, write(store, "content"))
+				}()
+			}
+
+			wg.Wait()
+			g.Assert(store.recent.Len()).Equal(5)
+		})
+
+		g.It("removes deleted files", func() {
+			store := newMemoryStore(0, 0)
+			key := write(store, "content")
+			g.Assert(store.DeleteFile(key)).Equal(nil)
+			_, e := read(store, key)
+			g.Assert(e == nil).Equal(false)
+			g.Assert(store.size).Equal(int64(0))
+			g.Assert(store.DeleteFile(key)).Equal(nil)
+		})
+
+		g.Describe("with a persistence", func() {
+			var store *memorystore
+			var persistence *testFilePersistence
+
+			g.BeforeEach(func() {
+				persistence = &testFilePersistence{files: make(map[string]*models.File)}
+				store = newMemoryStore(0, 0)
+				store.persistence = persistence
+			})
+
+			g.It("records the checksum and size of closed files", func() {
+				key := write(store, "<html></html>")
+				record := persistence.files[path.Base(key)]
+				g.Assert(record.Status).Equal("VALID")
+				g.Assert(record.Size).Equal(int64(13))
+				g.Assert(record.ContentType).Equal("text/html")
+				g.Assert(len(record.Checksum)).Equal(64)
+			})
+
+			g.It("detects files modified after they were written", func() {
+				key := write(store, "<html></html>")
+				store.files[key].Value.(*memoryEntry).content = []byte("<html>!</html>")
+
+				reader, e := store.FindFile(key)
+				g.Assert(e).Equal(nil)
+				defer reader.Close()
+				_, e = ioutil.ReadAll(reader)
+				g.Assert(e).Equal(ErrChecksumMismatch)
+			})
+
+			g.It("stores identical content once, keeping it until every file referencing it has been deleted", func() {
+				first, second := write(store, "<html></html>"), write(store, "<html></html>")
+				g.Assert(store.recent.Len()).Equal(1)
+				g.Assert(persistence.files[path.Base(second)].ObjectID).Equal(path.Base(first))
+
+				g.Assert(store.DeleteFile(first)).Equal(nil)
+				content, e := read(store, second)
+				g.Assert(e).Equal(nil)
+				g.Assert(content).Equal("<html></html>")
+
+				g.Assert(store.DeleteFile(second)).Equal(nil)
+				g.Assert(store.recent.Len()).Equal(0)
+				g.Assert(len(persistence.files)).Equal(0)
+			})
+
+			g.Describe("with gzip compression", func() {
+				var key string
+
+				g.BeforeEach(func() {
+					store.compression = "gzip"
+					key = write(store, "<html></html>")
+				})
+
+				g.It("stores the file compressed, returning the original content when finding the file", func() {
+					g.Assert(persistence.files[path.Base(key)].Encoding).Equal("gzip")
+					stored := store.files[key].Value.(*memoryEntry).content
+					g.Assert(bytes.HasPrefix(stored, []byte{0x1f, 0x8b})).Equal(true)
+
+					content, e := read(store, key)
+					g.Assert(e).Equal(nil)
+					g.Assert(content).Equal("<html></html>")
+				})
+
+				g.It("returns the compressed content along with its encoding when finding the range", func() {
+					file, e := store.FindFileRange(key, "")
+					g.Assert(e).Equal(nil)
+					defer file.Close()
+					g.Assert(file.Encoding).Equal("gzip")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package gendry
+
+import "fmt"
+import "time"
+import "net/http"
+import "github.com/prometheus/client_golang/prometheus"
+import "github.com/prometheus/client_golang/prometheus/promhttp"
+
+// DurationBuckets are the histogram buckets (in seconds) used for latencies.
+var DurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// SizeBuckets are the histogram buckets (in bytes) used for sizes, from 1KiB to 64MiB.
+var SizeBuckets = []float64{1 << 10, 1 << 12, 1 << 14, 1 << 16, 1 << 18, 1 << 20, 1 << 22, 1 << 24, 1 << 26}
+
+// Metrics is the prometheus registry the application's counters and histograms are registered with. A nil registry
+// (and the nil counters and histograms it returns) records nothing, so instrumentation is always optional.
+type Metrics struct {
+	registry *prometheus.Registry
+}
+
+// NewMetrics returns an empty registry.
+func NewMetrics() *Metrics {
+	return &Metrics{registry: prometheus.NewRegistry()}
+}
+
+// Counter registers a counter partitioned by the labels. Registering the same counter twice returns the existing
+// one; registering a name that is already used with a different type, help or labels panics.
+func (m *Metrics) Counter(name string, help string, labels ...string) *Counter {
+	if m == nil {
+		return nil
+	}
+
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	registered, ok := m.register(vec).(*prometheus.CounterVec)
+
+	if !ok {
+		panic(fmt.Sprintf("metric %s is already registered with a different type", name))
+	}
+
+	return &Counter{vec: registered}
+}
+
+// Histogram registers a histogram partitioned by the labels. Registering the same histogram twice returns the existing
+// one; registering a name that is already used with a different type, help or labels panics.
+func (m *Metrics) Histogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if m == nil {
+		return nil
+	}
+
+	opts := prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}
+	registered, ok := m.register(prometheus.NewHistogramVec(opts, labels)).(*prometheus.HistogramVec)
+
+	if !ok {
+		panic(fmt.Sprintf("metric %s is already registered with a different type", name))
+	}
+
+	return &Histogram{vec: registered}
+}
+
+// Handler returns the handler serving the registered metrics in the prometheus exposition format.
+func (m *Metrics) Handler(log LeveledLogger) http.Handler {
+	registry := prometheus.NewRegistry()
+
+	if m != nil {
+		registry = m.registry
+	}
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: metricsErrorLog{log}})
+}
+
+// register adds the collector to the registry, returning the previously registered collector if an identical one
+// (same name, help and labels) exists.
+func (m *Metrics) register(collector prometheus.Collector) prometheus.Collector {
+	e := m.registry.Register(collector)
+
+	if e == nil {
+		return collector
+	}
+
+	if existing, ok := e.(prometheus.AlreadyRegisteredError); ok {
+		return existing.ExistingCollector
+	}
+
+	panic(fmt.Sprintf("unable to register metric (error %v)", e))
+}
+
+// metricsErrorLog adapts a leveled logger to the logger used by the prometheus handler.
+type metricsErrorLog struct {
+	LeveledLogger
+}
+
+func (l metricsErrorLog) Println(values ...interface{}) {
+	l.Warnf("unable to write metrics: %s", fmt.Sprint(values...))
+}
+
+// Counter is a monotonically increasing value, partitioned by its label values.
+type Counter struct {
+	vec *prometheus.CounterVec
+}
+
+// Inc adds one to the series identified by the label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds the (non-negative) amount to the series identified by the label values.
+func (c *Counter) Add(amount float64, values ...string) {
+	if c == nil || amount < 0 {
+		return
+	}
+
+	c.vec.WithLabelValues(values...).Add(amount)
+}
+
+// Histogram counts observations into buckets, partitioned by its label values.
+type Histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+// Observe records the value in the series identified by the label values.
+func (h *Histogram) Observe(value float64, values ...string) {
+	if h == nil {
+		return
+	}
+
+	h.vec.WithLabelValues(values...).Observe(value)
+}
+
+// Since records the seconds elapsed since the start in the series identified by the label values.
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
@@ -0,0 +1,22 @@
+package gendry
+
+import "net/url"
+import "net/http"
+
+// NewMetricsAPI returns an api exposing the metrics of the registry to prometheus.
+func NewMetricsAPI(metrics *Metrics, log LeveledLogger) APIEndpoint {
+	api := &metricsAPI{
+		handler: metrics.Handler(log),
+	}
+
+	return api
+}
+
+type metricsAPI struct {
+	handler http.Handler
+}
+
+func (a *metricsAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	writer.Header().Set("Cache-Control", "no-store")
+	a.handler.ServeHTTP(writer, request)
+}
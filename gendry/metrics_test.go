@@ -0,0 +1,88 @@
+package gendry
+
+import "strings"
+import "testing"
+import "net/http/httptest"
+import "github.com/franela/goblin"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+
+func Test_Metrics(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("Metrics", func() {
+		var metrics *Metrics
+
+		g.BeforeEach(func() {
+			metrics = NewMetrics()
+		})
+
+		g.It("counts by label values", func() {
+			counter := metrics.Counter("requests_total", "Requests handled.", "route", "status")
+			counter.Inc("^/reports", "200")
+			counter.Add(2, "^/reports", "200")
+			counter.Inc("^/projects", "404")
+
+			g.Assert(testutil.ToFloat64(counter.vec.WithLabelValues("^/reports", "200"))).Equal(float64(3))
+			g.Assert(testutil.ToFloat64(counter.vec.WithLabelValues("^/projects", "404"))).Equal(float64(1))
+		})
+
+		g.It("observes histogram values by label values", func() {
+			histogram := metrics.Histogram("duration_seconds", "Latency.", []float64{0.1, 1}, "route")
+			histogram.Observe(0.05, "^/reports")
+			histogram.Observe(5, "^/projects")
+			g.Assert(testutil.CollectAndCount(histogram.vec)).Equal(2)
+		})
+
+		g.It("returns the existing metric when registering the same metric twice", func() {
+			metrics.Counter("requests_total", "Requests handled.", "route").Inc("a")
+			counter := metrics.Counter("requests_total", "Requests handled.", "route")
+			counter.Inc("a")
+			g.Assert(testutil.ToFloat64(counter.vec.WithLabelValues("a"))).Equal(float64(2))
+		})
+
+		g.It("panics when a name is registered with different labels", func() {
+			metrics.Counter("requests_total", "Requests handled.", "route")
+
+			defer func() {
+				g.Assert(recover() == nil).Equal(false)
+			}()
+
+			metrics.Counter("requests_total", "Requests handled.", "status")
+		})
+
+		g.It("panics when a name is registered with a different type", func() {
+			metrics.Counter("requests_total", "Requests handled.", "route")
+
+			defer func() {
+				g.Assert(recover() == nil).Equal(false)
+			}()
+
+			metrics.Histogram("requests_total", "Requests handled.", DurationBuckets, "route")
+		})
+
+		g.It("ignores negative counter increments", func() {
+			counter := metrics.Counter("requests_total", "Requests handled.")
+			counter.Inc()
+			counter.Add(-1)
+			g.Assert(testutil.ToFloat64(counter.vec.WithLabelValues())).Equal(float64(1))
+		})
+
+		g.It("serves the registered metrics", func() {
+			metrics.Counter("requests_total", "Requests handled.", "route").Inc("a")
+			recorder := httptest.NewRecorder()
+			NewMetricsAPI(metrics, &testLogger{}).Get(recorder, httptest.NewRequest("GET", "/metrics", nil), nil)
+			g.Assert(recorder.Code).Equal(200)
+			g.Assert(strings.Contains(recorder.Body.String(), "requests_total")).Equal(true)
+		})
+
+		g.It("records nothing with a nil registry", func() {
+			var empty *Metrics
+			empty.Counter("requests_total", "Requests handled.").Inc()
+			empty.Histogram("duration_seconds", "Latency.", DurationBuckets).Observe(1)
+			recorder := httptest.NewRecorder()
+			NewMetricsAPI(empty, &testLogger{}).Get(recorder, httptest.NewRequest("GET", "/metrics", nil), nil)
+			g.Assert(recorder.Code).Equal(200)
+			g.Assert(strings.Contains(recorder.Body.String(), "requests_total")).Equal(false)
+		})
+	})
+}
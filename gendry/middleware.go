@@ -0,0 +1,254 @@
+package gendry
+
+import "io"
+import "fmt"
+import "net"
+import "time"
+import "bufio"
+import "regexp"
+import "strconv"
+import "context"
+import "net/url"
+import "net/http"
+import "runtime/debug"
+import "github.com/satori/go.uuid"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// Middleware wraps an action with behavior shared by every request; middleware is given to NewRuntime, the first
+// middleware being the outermost.
+type Middleware func(Action) Action
+
+// chain wraps the action in the middleware, the first middleware being the outermost.
+func chain(action Action, middleware ...Middleware) Action {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		action = middleware[i](action)
+	}
+
+	return action
+}
+
+type requestIDKey struct{}
+
+var validRequestID = regexp.MustCompile("^[A-Za-z0-9._-]{1,128}$")
+
+// RequestID returns the id assigned to the request by the request id middleware, or an empty string.
+func RequestID(request *http.Request) string {
+	id, _ := request.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware assigns every request an id, available via RequestID and echoed in the request id header. A
+// well-formed id provided by the client (or a proxy) is kept so requests can be traced across services.
+func RequestIDMiddleware() Middleware {
+	return func(next Action) Action {
+		return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			id := request.Header.Get(constants.RequestIDHeader)
+
+			if validRequestID.MatchString(id) != true {
+				id = uuid.NewV4().String()
+			}
+
+			writer.Header().Set(constants.RequestIDHeader, id)
+			next(writer, request.WithContext(context.WithValue(request.Context(), requestIDKey{}, id)), params)
+		}
+	}
+}
+
+// RecoveryMiddleware logs panics raised while handling a request, responding with a 500 if nothing has been written.
+func RecoveryMiddleware(log LeveledLogger) Middleware {
+	return func(next Action) Action {
+		return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			tracker := &trackingWriter{ResponseWriter: writer}
+
+			defer func() {
+				failure := recover()
+
+				if failure == nil {
+					return
+				}
+
+				log.Errorf("panic serving %s %s (request %s): %v\n%s",
+					request.Method, request.URL.Path, RequestID(request), failure, debug.Stack())
+
+				if tracker.status != 0 {
+					return
+				}
+
+				tracker.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(tracker, "server-error")
+			}()
+
+			next(tracker, request, params)
+		}
+	}
+}
+
+// AccessLogMiddleware logs the method, path, status, size and duration of every request.
+func AccessLogMiddleware(log LeveledLogger) Middleware {
+	return func(next Action) Action {
+		return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			tracker, start := &trackingWriter{ResponseWriter: writer}, time.Now()
+
+			// Deferred so requests that panic (and are recovered further out) are logged too.
+			defer func() {
+				log.Infof("%s %s %d %dB %v (request %s)", request.Method, request.URL.Path, tracker.code(),
+					tracker.size, time.Since(start), RequestID(request))
+			}()
+
+			next(tracker, request, params)
+		}
+	}
+}
+
+// TimingMiddleware reports the time spent handling the request in the server timing header; the time is measured up
+// until the response headers are written.
+func TimingMiddleware() Middleware {
+	return func(next Action) Action {
+		return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			start := time.Now()
+
+			tracker := &trackingWriter{ResponseWriter: writer, beforeHeader: func(header http.Header) {
+				elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+				header.Set(constants.ServerTimingHeader, fmt.Sprintf("app;dur=%.3f", elapsed))
+			}}
+
+			next(tracker, request, params)
+		}
+	}
+}
+
+// knownMethods are the methods recorded by name in metrics; every other method is recorded as "OTHER".
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// MetricsMiddleware counts requests and records their latency by method, matched route and status. Requests that do
+// not match a route are recorded under the "none" route so unknown paths can not create unbounded series.
+func MetricsMiddleware(routes *RouteList, metrics *Metrics) Middleware {
+	requests := metrics.Counter("gendry_http_requests_total", "Requests handled.", "method", "route", "status")
+
+	durations := metrics.Histogram(
+		"gendry_http_request_duration_seconds", "Time spent handling requests.", DurationBuckets,
+		"method", "route", "status",
+	)
+
+	return func(next Action) Action {
+		return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			tracker, start, route, method := &trackingWriter{ResponseWriter: writer}, time.Now(), "none", "OTHER"
+
+			if _, known := knownMethods[request.Method]; known {
+				method = request.Method
+			}
+
+			if match, _ := routes.find(request.URL.EscapedPath()); match != nil {
+				route = match.Expression.String()
+			}
+
+			defer func() {
+				status := strconv.Itoa(tracker.code())
+				requests.Inc(method, route, status)
+				durations.Since(start, method, route, status)
+			}()
+
+			next(tracker, request, params)
+		}
+	}
+}
+
+// trackingWriter records the status and amount of bytes written to the response, optionally modifying the headers
+// before they are sent. Flushing, hijacking, close notification and ReadFrom are passed through to the wrapped writer.
+type trackingWriter struct {
+	http.ResponseWriter
+	status       int
+	size         int64
+	beforeHeader func(http.Header)
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+
+	w.status = status
+
+	if w.beforeHeader != nil {
+		w.beforeHeader(w.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	amount, e := w.ResponseWriter.Write(data)
+	w.size += int64(amount)
+	return amount, e
+}
+
+// code returns the status written to the response; responses that wrote nothing are sent as a 200 by net/http.
+func (w *trackingWriter) code() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+func (w *trackingWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *trackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	conn, buffer, e := hijacker.Hijack()
+
+	if e == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, buffer, e
+}
+
+// CloseNotify returns the wrapped writer's channel; writers without close notification never report a closed client.
+func (w *trackingWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+
+	return make(chan bool)
+}
+
+func (w *trackingWriter) ReadFrom(source io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if reader, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		amount, e := reader.ReadFrom(source)
+		w.size += amount
+		return amount, e
+	}
+
+	// The writer is wrapped so io.Copy can not call back into this method.
+	return io.Copy(struct{ io.Writer }{w}, source)
+}
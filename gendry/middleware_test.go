@@ -0,0 +1,145 @@
+package gendry
+
+import "io"
+import "strings"
+import "net/url"
+import "testing"
+import "net/http"
+import "net/http/httptest"
+import "github.com/franela/goblin"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+func Test_Middleware(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("middleware", func() {
+		var log *testLogger
+		var recorder *httptest.ResponseRecorder
+		var request *http.Request
+
+		g.BeforeEach(func() {
+			log = &testLogger{}
+			recorder = httptest.NewRecorder()
+			request = httptest.NewRequest("GET", "/reports", nil)
+		})
+
+		ok := func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+			io.WriteString(writer, "ok")
+		}
+
+		g.It("runs the middleware in order, the first being the outermost", func() {
+			order := make([]string, 0, 2)
+
+			named := func(name string) Middleware {
+				return func(next Action) Action {
+					return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+						order = append(order, name)
+						next(writer, request, params)
+					}
+				}
+			}
+
+			chain(ok, named("first"), named("second"))(recorder, request, nil)
+			g.Assert(order).Equal([]string{"first", "second"})
+			g.Assert(recorder.Body.String()).Equal("ok")
+		})
+
+		g.It("assigns request ids, echoing them in the response", func() {
+			var id string
+
+			RequestIDMiddleware()(func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+				id = RequestID(request)
+			})(recorder, request, nil)
+
+			g.Assert(id == "").Equal(false)
+			g.Assert(recorder.Header().Get(constants.RequestIDHeader)).Equal(id)
+		})
+
+		g.It("keeps well formed request ids provided by the client", func() {
+			request.Header.Set(constants.RequestIDHeader, "abc-123")
+			RequestIDMiddleware()(ok)(recorder, request, nil)
+			g.Assert(recorder.Header().Get(constants.RequestIDHeader)).Equal("abc-123")
+		})
+
+		g.It("replaces malformed request ids provided by the client", func() {
+			request.Header.Set(constants.RequestIDHeader, "abc 123\n")
+			RequestIDMiddleware()(ok)(recorder, request, nil)
+			g.Assert(recorder.Header().Get(constants.RequestIDHeader) == "abc 123\n").Equal(false)
+		})
+
+		g.It("responds with a 500 when the action panics", func() {
+			RecoveryMiddleware(log)(func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+				panic("boom")
+			})(recorder, request, nil)
+
+			g.Assert(recorder.Code).Equal(500)
+			g.Assert(len(log.messages)).Equal(1)
+			g.Assert(strings.Contains(log.messages[0], "boom")).Equal(true)
+		})
+
+		g.It("does not replace responses that were written before the action panicked", func() {
+			RecoveryMiddleware(log)(func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+				writer.WriteHeader(202)
+				panic("boom")
+			})(recorder, request, nil)
+
+			g.Assert(recorder.Code).Equal(202)
+			g.Assert(recorder.Body.Len()).Equal(0)
+		})
+
+		g.It("logs the status and size of every request, including recovered panics", func() {
+			action := chain(func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+				panic("boom")
+			}, RequestIDMiddleware(), AccessLogMiddleware(log), RecoveryMiddleware(&testLogger{}))
+
+			action(recorder, request, nil)
+			g.Assert(len(log.messages)).Equal(1)
+			g.Assert(strings.HasPrefix(log.messages[0], "GET /reports 500 12B")).Equal(true)
+			g.Assert(strings.Contains(log.messages[0], recorder.Header().Get(constants.RequestIDHeader))).Equal(true)
+		})
+
+		g.It("records requests by method, matched route and status", func() {
+			metrics := NewMetrics()
+			routes := NewRouteList()
+			routes.Add(0, "^/reports", &testRoute{})
+			middleware := MetricsMiddleware(routes, metrics)
+
+			middleware(ok)(recorder, request, nil)
+			middleware(ok)(httptest.NewRecorder(), httptest.NewRequest("BREW", "/coffee", nil), nil)
+
+			requests := metrics.Counter("gendry_http_requests_total", "Requests handled.", "method", "route", "status")
+			g.Assert(testutil.ToFloat64(requests.vec.WithLabelValues("GET", "^/reports", "200"))).Equal(float64(1))
+			g.Assert(testutil.ToFloat64(requests.vec.WithLabelValues("OTHER", "none", "200"))).Equal(float64(1))
+		})
+
+		g.It("passes flushes through to the response, writing the headers first", func() {
+			TimingMiddleware()(func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+				writer.(http.Flusher).Flush()
+			})(recorder, request, nil)
+
+			g.Assert(recorder.Flushed).Equal(true)
+			g.Assert(recorder.Header().Get(constants.ServerTimingHeader) == "").Equal(false)
+		})
+
+		g.It("counts bytes copied to the response using ReadFrom", func() {
+			tracker := &trackingWriter{ResponseWriter: recorder}
+			amount, e := io.Copy(tracker, strings.NewReader("hello"))
+			g.Assert(e).Equal(nil)
+			g.Assert(amount).Equal(int64(5))
+			g.Assert(tracker.size).Equal(int64(5))
+			g.Assert(recorder.Body.String()).Equal("hello")
+		})
+
+		g.It("returns an error when hijacking a response that does not support it", func() {
+			_, _, e := (&trackingWriter{ResponseWriter: recorder}).Hijack()
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("reports the time spent handling the request", func() {
+			TimingMiddleware()(ok)(recorder, request, nil)
+			g.Assert(strings.HasPrefix(recorder.Header().Get(constants.ServerTimingHeader), "app;dur=")).Equal(true)
+			g.Assert(recorder.Body.String()).Equal("ok")
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package models
+
+//go:generate marlowc -input ./coverage_file.go
+
+// CoverageFile records hold the coverage of a single source file within a report, including its encoded blocks.
+type CoverageFile struct {
+	ID         uint    `marlow:"column=id&autoIncrement=true"`
+	ReportID   string  `marlow:"column=report_id"`
+	FileName   string  `marlow:"column=file_name"`
+	Statements int     `marlow:"column=statements"`
+	Covered    int     `marlow:"column=covered"`
+	Coverage   float64 `marlow:"column=coverage"`
+	Blocks     string  `marlow:"column=blocks"`
+}
@@ -1,10 +1,18 @@
 package models
 
+import "time"
+
 //go:generate marlowc -input ./file.go
 
 // File records provide a database lookup for persisted files on the filestore.
 type File struct {
-	ID       uint   `marlow:"column=id&autoIncrement=true"`
-	SystemID string `marlow:"column=system_id"`
-	Status   string `marlow:"column=status"`
+	ID          uint      `marlow:"column=id&autoIncrement=true"`
+	SystemID    string    `marlow:"column=system_id"`
+	Status      string    `marlow:"column=status"`
+	CreatedAt   time.Time `marlow:"column=created_at"`
+	ContentType string    `marlow:"column=content_type"`
+	Size        int64     `marlow:"column=size"`
+	Checksum    string    `marlow:"column=checksum"`
+	ObjectID    string    `marlow:"column=object_id"`
+	Encoding    string    `marlow:"column=encoding"`
 }
@@ -1,13 +1,22 @@
 package models
 
+import "time"
+
 //go:generate marlowc -input ./report.go
 
 // Report records represent a persisted version of a go coverage report (created from txt and html files)
 type Report struct {
-	ID         uint    `marlow:"column=id&autoIncrement=true"`
-	SystemID   string  `marlow:"column=system_id"`
-	ProjectID  string  `marlow:"column=project_id"`
-	HTMLFileID string  `marlow:"column=html_file_id"`
-	Coverage   float64 `marlow:"column=coverage"`
-	Tag        string  `marlow:"column=tag"`
+	ID          uint      `marlow:"column=id&autoIncrement=true"`
+	SystemID    string    `marlow:"column=system_id"`
+	ProjectID   string    `marlow:"column=project_id"`
+	HTMLFileID  string    `marlow:"column=html_file_id"`
+	Coverage    float64   `marlow:"column=coverage"`
+	Tag         string    `marlow:"column=tag"`
+	CreatedAt   time.Time `marlow:"column=created_at"`
+	Commit      string    `marlow:"column=commit_sha"`
+	Branch      string    `marlow:"column=branch"`
+	PullRequest string    `marlow:"column=pull_request"`
+	BuildURL    string    `marlow:"column=build_url"`
+	Author      string    `marlow:"column=author"`
+	BuiltAt     time.Time `marlow:"column=built_at"`
 }
@@ -1,7 +1,25 @@
 package gendry
 
+import "strconv"
+import "net/http"
+import "github.com/dadleyy/gendry/gendry/constants"
+
 type pagingInfo struct {
 	total  int
 	limit  int
 	offset int
 }
+
+func parsePagingInfo(request *http.Request) pagingInfo {
+	paging := pagingInfo{limit: 10, offset: 0}
+
+	if offset, e := strconv.Atoi(request.URL.Query().Get(constants.OffsetParamName)); e == nil {
+		paging.offset = offset
+	}
+
+	if limit, e := strconv.Atoi(request.URL.Query().Get(constants.LimitParamName)); e == nil {
+		paging.limit = limit
+	}
+
+	return paging
+}
@@ -0,0 +1,319 @@
+package gendry
+
+import "sort"
+import "time"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// The fakes below stand in for the generated persistence of projects, reports, coverage files and file records across
+// the test suites; each keeps its rows in memory and implements only the methods the suites exercise.
+
+type testProjectPersistence struct {
+	models.ProjectStore
+	projects []*models.Project
+}
+
+func (p *testProjectPersistence) FindProjects(bp *models.ProjectBlueprint) ([]*models.Project, error) {
+	results := make([]*models.Project, 0)
+
+	for _, project := range p.projects {
+		if len(bp.Token) > 0 && bp.Token[0] != project.Token {
+			continue
+		}
+
+		if len(bp.SystemID) > 0 && bp.SystemID[0] != project.SystemID {
+			continue
+		}
+
+		if len(bp.Name) > 0 && bp.Name[0] != project.Name {
+			continue
+		}
+
+		results = append(results, project)
+	}
+
+	return results, nil
+}
+
+func (p *testProjectPersistence) UpdateProjectCoverageThresholds(
+	value string, bp *models.ProjectBlueprint,
+) (int64, error, string) {
+	projects, _ := p.FindProjects(bp)
+
+	for _, project := range projects {
+		project.CoverageThresholds = value
+	}
+
+	return int64(len(projects)), nil, ""
+}
+
+func (p *testProjectPersistence) UpdateProjectQualityGate(
+	value string, bp *models.ProjectBlueprint,
+) (int64, error, string) {
+	projects, _ := p.FindProjects(bp)
+
+	for _, project := range projects {
+		project.QualityGate = value
+	}
+
+	return int64(len(projects)), nil, ""
+}
+
+type testReportPersistence struct {
+	models.ReportStore
+	reports []*models.Report
+	updated []string
+	found   *models.ReportBlueprint
+}
+
+// FindReports filters by the first project, system id and tag of the blueprint. Reports are expected to be stored
+// oldest first; descending orders list them in reverse.
+func (p *testReportPersistence) FindReports(bp *models.ReportBlueprint) ([]*models.Report, error) {
+	results := make([]*models.Report, 0)
+	p.found = bp
+
+	for i := range p.reports {
+		r := p.reports[i]
+
+		if bp.OrderDirection == "DESC" {
+			r = p.reports[len(p.reports)-1-i]
+		}
+
+		if len(bp.ProjectID) > 0 && bp.ProjectID[0] != r.ProjectID {
+			continue
+		}
+
+		if len(bp.SystemID) > 0 && bp.SystemID[0] != r.SystemID {
+			continue
+		}
+
+		if len(bp.Tag) > 0 && bp.Tag[0] != r.Tag {
+			continue
+		}
+
+		results = append(results, r)
+	}
+
+	if bp.Limit > 0 && len(results) > bp.Limit {
+		results = results[:bp.Limit]
+	}
+
+	return results, nil
+}
+
+func (p *testReportPersistence) CountReports(bp *models.ReportBlueprint) (int, error) {
+	unlimited := *bp
+	unlimited.Limit = 0
+	results, e := p.FindReports(&unlimited)
+	p.found = bp
+	return len(results), e
+}
+
+func (p *testReportPersistence) DeleteReports(bp *models.ReportBlueprint) (int64, error) {
+	remaining := make([]*models.Report, 0, len(p.reports))
+
+	for _, r := range p.reports {
+		if r.SystemID != bp.SystemID[0] {
+			remaining = append(remaining, r)
+		}
+	}
+
+	removed := len(p.reports) - len(remaining)
+	p.reports = remaining
+	return int64(removed), nil
+}
+
+func (p *testReportPersistence) SelectHTMLFileIDs(bp *models.ReportBlueprint) ([]string, error) {
+	results := make([]string, 0, len(p.reports))
+
+	for _, r := range p.reports {
+		selected := len(bp.HTMLFileID) == 0
+
+		for _, id := range bp.HTMLFileID {
+			selected = selected || id == r.HTMLFileID
+		}
+
+		if selected {
+			results = append(results, r.HTMLFileID)
+		}
+	}
+
+	return results, nil
+}
+
+// update applies the change to every report matched by the blueprint, recording the name of the updated field.
+func (p *testReportPersistence) update(field string, bp *models.ReportBlueprint, change func(*models.Report)) int64 {
+	reports, _ := p.FindReports(bp)
+
+	for _, r := range reports {
+		change(r)
+	}
+
+	p.updated = append(p.updated, field)
+	return int64(len(reports))
+}
+
+func (p *testReportPersistence) UpdateReportTag(value string, bp *models.ReportBlueprint) (int64, error, string) {
+	return p.update("tag", bp, func(r *models.Report) { r.Tag = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportCommit(value string, bp *models.ReportBlueprint) (int64, error, string) {
+	return p.update("commit", bp, func(r *models.Report) { r.Commit = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportBranch(value string, bp *models.ReportBlueprint) (int64, error, string) {
+	return p.update("branch", bp, func(r *models.Report) { r.Branch = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportPullRequest(
+	value string, bp *models.ReportBlueprint,
+) (int64, error, string) {
+	return p.update("pull_request", bp, func(r *models.Report) { r.PullRequest = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportBuildURL(value string, bp *models.ReportBlueprint) (int64, error, string) {
+	return p.update("build_url", bp, func(r *models.Report) { r.BuildURL = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportAuthor(value string, bp *models.ReportBlueprint) (int64, error, string) {
+	return p.update("author", bp, func(r *models.Report) { r.Author = value }), nil, ""
+}
+
+func (p *testReportPersistence) UpdateReportBuiltAt(
+	value time.Time, bp *models.ReportBlueprint,
+) (int64, error, string) {
+	return p.update("timestamp", bp, func(r *models.Report) { r.BuiltAt = value }), nil, ""
+}
+
+type testCoveragePersistence struct {
+	models.CoverageFileStore
+	deleted []string
+}
+
+func (p *testCoveragePersistence) FindCoverageFiles(bp *models.CoverageFileBlueprint) ([]*models.CoverageFile, error) {
+	return nil, nil
+}
+
+func (p *testCoveragePersistence) DeleteCoverageFiles(bp *models.CoverageFileBlueprint) (int64, error) {
+	p.deleted = append(p.deleted, bp.ReportID...)
+	return 1, nil
+}
+
+type testFilePersistence struct {
+	models.FileStore
+	files   map[string]*models.File
+	queries int
+}
+
+func (p *testFilePersistence) CreateFiles(files ...models.File) (int64, error) {
+	for i := range files {
+		p.files[files[i].SystemID] = &files[i]
+	}
+
+	return int64(len(files)), nil
+}
+
+func (p *testFilePersistence) UpdateFileStatus(status string, bp *models.FileBlueprint) (int64, error, string) {
+	for _, id := range bp.SystemID {
+		if f, ok := p.files[id]; ok {
+			f.Status = status
+		}
+	}
+
+	return 1, nil, ""
+}
+
+func (p *testFilePersistence) UpdateFileChecksum(checksum string, bp *models.FileBlueprint) (int64, error, string) {
+	for _, id := range bp.SystemID {
+		if f, ok := p.files[id]; ok {
+			f.Checksum = checksum
+		}
+	}
+
+	return 1, nil, ""
+}
+
+func (p *testFilePersistence) UpdateFileSize(size int64, bp *models.FileBlueprint) (int64, error, string) {
+	for _, id := range bp.SystemID {
+		if f, ok := p.files[id]; ok {
+			f.Size = size
+		}
+	}
+
+	return 1, nil, ""
+}
+
+func (p *testFilePersistence) UpdateFileObjectID(object string, bp *models.FileBlueprint) (int64, error, string) {
+	for _, id := range bp.SystemID {
+		if f, ok := p.files[id]; ok {
+			f.ObjectID = object
+		}
+	}
+
+	return 1, nil, ""
+}
+
+// matches applies the blueprint's system id, object id, checksum and status filters to the file.
+func (p *testFilePersistence) matches(f *models.File, bp *models.FileBlueprint) bool {
+	contains := func(values []string, value string) bool {
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return len(values) == 0
+	}
+
+	if bp.Inclusive {
+		system := contains(bp.SystemID, f.SystemID) && len(bp.SystemID) > 0
+		return system || contains(bp.ObjectID, f.ObjectID) && len(bp.ObjectID) > 0
+	}
+
+	window := bp.CreatedAtRange
+
+	if len(window) == 2 && (f.CreatedAt.Before(window[0]) || f.CreatedAt.After(window[1])) {
+		return false
+	}
+
+	return contains(bp.SystemID, f.SystemID) && contains(bp.ObjectID, f.ObjectID) &&
+		contains(bp.Checksum, f.Checksum) && contains(bp.Status, f.Status) && contains(bp.Encoding, f.Encoding)
+}
+
+// FindFiles returns the matching files ordered by system id, applying the blueprint's offset and limit.
+func (p *testFilePersistence) FindFiles(bp *models.FileBlueprint) ([]*models.File, error) {
+	results := make([]*models.File, 0, len(p.files))
+	p.queries++
+
+	for _, f := range p.files {
+		if p.matches(f, bp) {
+			results = append(results, f)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].SystemID < results[j].SystemID })
+
+	if bp.Offset >= len(results) {
+		return []*models.File{}, nil
+	}
+
+	results = results[bp.Offset:]
+
+	if bp.Limit > 0 && len(results) > bp.Limit {
+		results = results[:bp.Limit]
+	}
+
+	return results, nil
+}
+
+func (p *testFilePersistence) CountFiles(bp *models.FileBlueprint) (int, error) {
+	results, e := p.FindFiles(bp)
+	return len(results), e
+}
+
+func (p *testFilePersistence) DeleteFiles(bp *models.FileBlueprint) (int64, error) {
+	for _, id := range bp.SystemID {
+		delete(p.files, id)
+	}
+
+	return int64(len(bp.SystemID)), nil
+}
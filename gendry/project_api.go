@@ -3,7 +3,6 @@ package gendry
 import "io"
 import "fmt"
 import "bytes"
-import "strconv"
 import "net/url"
 import "net/http"
 import "encoding/json"
@@ -12,10 +11,13 @@ import "github.com/dadleyy/gendry/gendry/models"
 import "github.com/dadleyy/gendry/gendry/constants"
 
 // NewProjectAPI creates the api endpoint that is able to create new projects.
-func NewProjectAPI(store models.ProjectStore, log LeveledLogger) APIEndpoint {
+func NewProjectAPI(
+	store models.ProjectStore, re models.ReportStore, cf models.CoverageFileStore, fs FileStore, log LeveledLogger,
+) APIEndpoint {
 	api := &projectAPI{
 		LeveledLogger: log,
 		store:         store,
+		cleaner:       &reportCleaner{reports: re, coverageFiles: cf, filestore: fs},
 	}
 
 	return api
@@ -24,11 +26,12 @@ func NewProjectAPI(store models.ProjectStore, log LeveledLogger) APIEndpoint {
 type projectAPI struct {
 	LeveledLogger
 	jsonResponder
-	store models.ProjectStore
+	store   models.ProjectStore
+	cleaner *reportCleaner
 }
 
 func (a *projectAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	paging := a.paging(request)
+	paging := parsePagingInfo(request)
 
 	blueprint := &models.ProjectBlueprint{
 		Offset: paging.offset,
@@ -46,13 +49,7 @@ func (a *projectAPI) Get(writer http.ResponseWriter, request *http.Request, para
 	results := make([]interface{}, len(projects))
 
 	for i, p := range projects {
-		item := struct {
-			ID       uint   `json:"id"`
-			SystemID string `json:"system_id"`
-			Name     string `json:"name"`
-		}{p.ID, p.SystemID, p.Name}
-
-		results[i] = item
+		results[i] = projectDetails(p)
 	}
 
 	paging.total, e = a.store.CountProjects(blueprint)
@@ -67,52 +64,52 @@ func (a *projectAPI) Get(writer http.ResponseWriter, request *http.Request, para
 }
 
 func (a *projectAPI) Delete(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	projectID := request.URL.Query().Get(constants.ProjectIDParamName)
-
-	projects, e := a.store.FindProjects(&models.ProjectBlueprint{
-		Token: []string{request.Header.Get(constants.ProjectAuthTokenAPIHeader)},
-	})
+	project, e := a.authorize(request)
 
-	if e != nil || len(projects) != 1 {
-		a.Warnf("invalid project %s (error %v)", request.Header.Get(constants.ProjectAuthTokenAPIHeader), e)
+	if e != nil {
+		a.Warnf("invalid project %s (error %v)", request.URL.Query().Get(constants.ProjectIDParamName), e)
 		a.renderError(writer, "invalid-project")
 		return
 	}
 
-	if projects[0].SystemID != projectID && fmt.Sprintf("%d", projects[0].ID) != projectID {
-		a.Warnf("invalid project %s (found %v)", projectID, projects[0].SystemID)
-		a.renderError(writer, "invalid-project")
+	// The project's reports (and their stored files) are removed first so a failure leaves the project in place for
+	// the deletion to be retried.
+	removed, e := a.cleaner.deleteReports(&models.ReportBlueprint{ProjectID: []string{project.SystemID}})
+
+	if e != nil {
+		a.Errorf("unable to delete reports of project %s after %d (error %v)", project.SystemID, removed, e)
+		a.renderError(writer, "server-error")
 		return
 	}
 
 	blueprint := &models.ProjectBlueprint{
-		SystemID: []string{projects[0].SystemID},
+		SystemID: []string{project.SystemID},
 	}
 
 	if _, e := a.store.DeleteProjects(blueprint); e != nil {
-		a.Errorf("unable to delete project %s (error %v)", projects[0].SystemID, e)
+		a.Errorf("unable to delete project %s (error %v)", project.SystemID, e)
 		a.renderError(writer, "server-error")
 		return
 	}
 
-	a.Infof("deleted project %s (id %s)", projects[0].Name, projects[0].SystemID)
+	a.Infof("deleted project %s (id %s) and %d reports", project.Name, project.SystemID, removed)
 
 	a.renderSuccess(writer, nil)
 	return
 }
 
 func (a *projectAPI) Post(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	defer request.Body.Close()
-	decoder := json.NewDecoder(request.Body)
-	project := struct {
-		Name string `json:"name"`
-	}{}
+	project, e := a.decodeSettings(request)
 
-	if e := decoder.Decode(&project); e != nil {
-		a.renderError(writer, "invalid-project")
+	if e != nil {
+		a.renderError(writer, e.Error())
 		return
 	}
 
+	if project.Gate == nil {
+		project.Gate = &qualityGate{}
+	}
+
 	c, e := a.store.CountProjects(&models.ProjectBlueprint{
 		Name: []string{project.Name},
 	})
@@ -133,9 +130,11 @@ func (a *projectAPI) Post(writer http.ResponseWriter, request *http.Request, par
 	token := a.generateToken()
 
 	id, e := a.store.CreateProjects(models.Project{
-		Name:     project.Name,
-		SystemID: systemID,
-		Token:    token,
+		Name:               project.Name,
+		SystemID:           systemID,
+		Token:              token,
+		CoverageThresholds: project.Thresholds.String(),
+		QualityGate:        project.Gate.String(),
 	})
 
 	if e != nil {
@@ -147,29 +146,139 @@ func (a *projectAPI) Post(writer http.ResponseWriter, request *http.Request, par
 	a.Infof("created new project %s (id %s)", project.Name, systemID)
 
 	a.renderSuccess(writer, struct {
-		ID       int64  `json:"id"`
-		SystemID string `json:"system_id"`
-		Token    string `json:"token"`
-		Name     string `json:"name"`
-	}{id, systemID, token, project.Name})
+		ID         int64              `json:"id"`
+		SystemID   string             `json:"system_id"`
+		Token      string             `json:"token"`
+		Name       string             `json:"name"`
+		Thresholds coverageThresholds `json:"thresholds"`
+		Gate       *qualityGate       `json:"gate"`
+	}{id, systemID, token, project.Name, project.Thresholds, project.Gate})
 }
 
-func (a *projectAPI) generateToken() string {
-	output := new(bytes.Buffer)
-	io.Copy(output, newTokenGenerator(20))
-	return output.String()
+// Patch updates the settings of the project identified by the project id query param.
+func (a *projectAPI) Patch(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	settings, e := a.decodeSettings(request)
+
+	if e != nil {
+		a.renderError(writer, e.Error())
+		return
+	}
+
+	a.updateSettings(writer, request, settings)
 }
 
-func (a *projectAPI) paging(request *http.Request) pagingInfo {
-	paging := pagingInfo{limit: 10, offset: 0}
+// decodeSettings reads and validates the project settings from the request body; the returned error is the one that
+// should be rendered to the client.
+func (a *projectAPI) decodeSettings(request *http.Request) (projectSettings, error) {
+	defer request.Body.Close()
+	decoder := json.NewDecoder(request.Body)
+	settings := projectSettings{}
 
-	if offset, e := strconv.Atoi(request.URL.Query().Get(constants.OffsetParamName)); e == nil {
-		paging.offset = offset
+	if e := decoder.Decode(&settings); e != nil {
+		return settings, fmt.Errorf("invalid-project")
 	}
 
-	if limit, e := strconv.Atoi(request.URL.Query().Get(constants.LimitParamName)); e == nil {
-		paging.limit = limit
+	if e := settings.Thresholds.validate(); e != nil {
+		a.Warnf("invalid thresholds: %s", e.Error())
+		return settings, fmt.Errorf("invalid-thresholds")
 	}
 
-	return paging
+	if settings.Gate != nil {
+		if e := settings.Gate.validate(); e != nil {
+			a.Warnf("invalid quality gate: %s", e.Error())
+			return settings, fmt.Errorf("invalid-gate")
+		}
+	}
+
+	return settings, nil
+}
+
+// updateSettings replaces the coverage color bands and/or quality gate of the authenticated project; settings that
+// were not present in the request body are left untouched.
+func (a *projectAPI) updateSettings(writer http.ResponseWriter, request *http.Request, settings projectSettings) {
+	project, e := a.authorize(request)
+
+	if e != nil {
+		a.Warnf("unauthorized project update (error %v)", e)
+		a.renderError(writer, "invalid-project")
+		return
+	}
+
+	blueprint := &models.ProjectBlueprint{
+		SystemID: []string{project.SystemID},
+	}
+
+	if settings.Thresholds != nil {
+		project.CoverageThresholds = settings.Thresholds.String()
+
+		if _, e, _ := a.store.UpdateProjectCoverageThresholds(project.CoverageThresholds, blueprint); e != nil {
+			a.Errorf("unable to update thresholds for project %s (error %v)", project.SystemID, e)
+			a.renderError(writer, "server-error")
+			return
+		}
+	}
+
+	if settings.Gate != nil {
+		project.QualityGate = settings.Gate.String()
+
+		if _, e, _ := a.store.UpdateProjectQualityGate(project.QualityGate, blueprint); e != nil {
+			a.Errorf("unable to update quality gate for project %s (error %v)", project.SystemID, e)
+			a.renderError(writer, "server-error")
+			return
+		}
+	}
+
+	a.Infof("updated settings for project %s (id %s)", project.Name, project.SystemID)
+
+	a.renderSuccess(writer, projectDetails(project))
+}
+
+// authorize returns the project identified by the project id query param if the auth token header matches it.
+func (a *projectAPI) authorize(request *http.Request) (*models.Project, error) {
+	projectID := request.URL.Query().Get(constants.ProjectIDParamName)
+
+	projects, e := a.store.FindProjects(&models.ProjectBlueprint{
+		Token: []string{request.Header.Get(constants.ProjectAuthTokenAPIHeader)},
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(projects) != 1 {
+		return nil, fmt.Errorf("invalid-token")
+	}
+
+	if projects[0].SystemID != projectID && fmt.Sprintf("%d", projects[0].ID) != projectID {
+		return nil, fmt.Errorf("invalid-project")
+	}
+
+	return projects[0], nil
+}
+
+func (a *projectAPI) generateToken() string {
+	output := new(bytes.Buffer)
+	io.Copy(output, newTokenGenerator(20))
+	return output.String()
+}
+
+// projectSettings holds the configurable values of a project that may be provided during creation or update.
+type projectSettings struct {
+	Name       string             `json:"name"`
+	Thresholds coverageThresholds `json:"thresholds"`
+	Gate       *qualityGate       `json:"gate"`
+}
+
+// projectDetails returns the publicly visible fields of a project record.
+func projectDetails(p *models.Project) interface{} {
+	thresholds, _ := parseCoverageThresholds(p.CoverageThresholds)
+	gate, _ := parseQualityGate(p.QualityGate)
+
+	return struct {
+		ID         uint               `json:"id"`
+		SystemID   string             `json:"system_id"`
+		Name       string             `json:"name"`
+		Thresholds coverageThresholds `json:"thresholds"`
+		Gate       *qualityGate       `json:"gate"`
+	}{p.ID, p.SystemID, p.Name, thresholds, gate}
 }
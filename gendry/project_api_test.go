@@ -1 +1,70 @@
 package gendry
+
+import "strings"
+import "testing"
+import "net/http/httptest"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+func Test_ProjectAPI(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("ProjectAPI", func() {
+		var api *projectAPI
+		var project *models.Project
+		var recorder *httptest.ResponseRecorder
+
+		g.BeforeEach(func() {
+			project = &models.Project{
+				ID:                 1,
+				SystemID:           "project-1",
+				Token:              "secret",
+				CoverageThresholds: "0:red,80:green",
+			}
+
+			recorder = httptest.NewRecorder()
+			api = &projectAPI{
+				LeveledLogger: &testLogger{},
+				store:         &testProjectPersistence{projects: []*models.Project{project}},
+			}
+		})
+
+		patch := func(token string, body string) {
+			request := httptest.NewRequest("PATCH", "/projects?project_id=project-1", strings.NewReader(body))
+			request.Header.Set(constants.ProjectAuthTokenAPIHeader, token)
+			api.Patch(recorder, request, nil)
+		}
+
+		g.Describe("Patch", func() {
+			g.It("rejects requests with an invalid token", func() {
+				patch("wrong", `{"gate":{"minimum_coverage":50}}`)
+				g.Assert(recorder.Code).Equal(422)
+				g.Assert(strings.Contains(recorder.Body.String(), "invalid-project")).Equal(true)
+				g.Assert(project.QualityGate).Equal("")
+			})
+
+			g.It("updates the quality gate, leaving the thresholds untouched", func() {
+				patch("secret", `{"gate":{"minimum_coverage":50}}`)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(project.QualityGate == "").Equal(false)
+				g.Assert(project.CoverageThresholds).Equal("0:red,80:green")
+			})
+
+			g.It("updates the thresholds, leaving the quality gate untouched", func() {
+				project.QualityGate = "minimum=50"
+				patch("secret", `{"thresholds":[{"minimum":0,"color":"red"},{"minimum":90,"color":"green"}]}`)
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(project.CoverageThresholds).Equal("0:red,90:green")
+				g.Assert(project.QualityGate).Equal("minimum=50")
+			})
+
+			g.It("rejects invalid thresholds", func() {
+				patch("secret", `{"thresholds":[{"minimum":120,"color":"red"}]}`)
+				g.Assert(recorder.Code).Equal(422)
+				g.Assert(strings.Contains(recorder.Body.String(), "invalid-thresholds")).Equal(true)
+				g.Assert(project.CoverageThresholds).Equal("0:red,80:green")
+			})
+		})
+	})
+}
@@ -0,0 +1,286 @@
+package gendry
+
+import "fmt"
+import "strings"
+import "strconv"
+import "github.com/dadleyy/gendry/gendry/models"
+
+const (
+	gateMinimumKey        = "minimum"
+	gateMaximumDropKey    = "drop"
+	gateBaseTagKey        = "base"
+	gateChangedMinimumKey = "changed"
+)
+
+// qualityGate holds the rules a report must satisfy to pass a project's gate; nil rules are not checked.
+type qualityGate struct {
+	MinimumCoverage    *float64 `json:"minimum_coverage,omitempty"`
+	MaximumDrop        *float64 `json:"maximum_drop,omitempty"`
+	BaseTag            string   `json:"base_tag,omitempty"`
+	ChangedFileMinimum *float64 `json:"changed_file_minimum,omitempty"`
+}
+
+// gateCheck is the outcome of a single gate rule.
+type gateCheck struct {
+	Name     string  `json:"name"`
+	Passed   bool    `json:"passed"`
+	Expected float64 `json:"expected"`
+	Actual   float64 `json:"actual"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// gateResult is the machine-readable outcome of running a report through a project's quality gate.
+type gateResult struct {
+	Passed   bool        `json:"passed"`
+	Report   string      `json:"report"`
+	Base     string      `json:"base,omitempty"`
+	Coverage float64     `json:"coverage"`
+	Checks   []gateCheck `json:"checks"`
+}
+
+// reasons returns the reason of every failed check.
+func (r *gateResult) reasons() []string {
+	results := make([]string, 0, len(r.Checks))
+
+	for _, c := range r.Checks {
+		if c.Passed != true {
+			results = append(results, c.Reason)
+		}
+	}
+
+	return results
+}
+
+// parseQualityGate decodes the persisted, comma separated "key=value" representation of a project's gate.
+func parseQualityGate(encoded string) (*qualityGate, error) {
+	gate := &qualityGate{}
+
+	if strings.TrimSpace(encoded) == "" {
+		return gate, nil
+	}
+
+	for _, rule := range strings.Split(encoded, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid-gate-rule: %s", rule)
+		}
+
+		if parts[0] == gateBaseTagKey {
+			gate.BaseTag = parts[1]
+			continue
+		}
+
+		value, e := strconv.ParseFloat(parts[1], 64)
+
+		if e != nil {
+			return nil, fmt.Errorf("invalid-gate-rule: %s", rule)
+		}
+
+		switch parts[0] {
+		case gateMinimumKey:
+			gate.MinimumCoverage = &value
+		case gateMaximumDropKey:
+			gate.MaximumDrop = &value
+		case gateChangedMinimumKey:
+			gate.ChangedFileMinimum = &value
+		default:
+			return nil, fmt.Errorf("invalid-gate-rule: %s", rule)
+		}
+	}
+
+	return gate, gate.validate()
+}
+
+// validate ensures the percentages are within 0-100 and that the base tag can be persisted.
+func (g *qualityGate) validate() error {
+	for _, value := range []*float64{g.MinimumCoverage, g.MaximumDrop, g.ChangedFileMinimum} {
+		if value != nil && (*value < 0 || *value > 100) {
+			return fmt.Errorf("invalid-gate-percentage: %f", *value)
+		}
+	}
+
+	if strings.ContainsAny(g.BaseTag, ",=") {
+		return fmt.Errorf("invalid-gate-base: %s", g.BaseTag)
+	}
+
+	return nil
+}
+
+// String returns the persisted representation of the gate.
+func (g *qualityGate) String() string {
+	rules := make([]string, 0, 4)
+	format := func(key string, value float64) string {
+		return fmt.Sprintf("%s=%s", key, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+
+	if g.MinimumCoverage != nil {
+		rules = append(rules, format(gateMinimumKey, *g.MinimumCoverage))
+	}
+
+	if g.MaximumDrop != nil {
+		rules = append(rules, format(gateMaximumDropKey, *g.MaximumDrop))
+	}
+
+	if g.BaseTag != "" {
+		rules = append(rules, fmt.Sprintf("%s=%s", gateBaseTagKey, g.BaseTag))
+	}
+
+	if g.ChangedFileMinimum != nil {
+		rules = append(rules, format(gateChangedMinimumKey, *g.ChangedFileMinimum))
+	}
+
+	return strings.Join(rules, ",")
+}
+
+// evaluate runs the report through each configured rule. The base report may be nil, in which case the drop rule passes
+// since there is nothing to compare against. The changed file rule checks the files of the report matching the paths
+// changed in the diff being checked; without changed paths there is nothing for it to check.
+func (g *qualityGate) evaluate(
+	report, base *models.Report, files []*models.CoverageFile, changed []string,
+) *gateResult {
+	result := &gateResult{
+		Passed:   true,
+		Report:   report.SystemID,
+		Coverage: report.Coverage,
+		Checks:   make([]gateCheck, 0, 3),
+	}
+
+	add := func(check gateCheck) {
+		result.Checks = append(result.Checks, check)
+		result.Passed = result.Passed && check.Passed
+	}
+
+	if g.MinimumCoverage != nil {
+		check := gateCheck{Name: "minimum_coverage", Expected: *g.MinimumCoverage, Actual: report.Coverage}
+		check.Passed = report.Coverage >= *g.MinimumCoverage
+
+		if check.Passed != true {
+			check.Reason = fmt.Sprintf(
+				"coverage %.2f%% is below the minimum of %.2f%%", report.Coverage, check.Expected,
+			)
+		}
+
+		add(check)
+	}
+
+	if base != nil {
+		result.Base = base.SystemID
+	}
+
+	if base != nil && g.MaximumDrop != nil {
+		drop := base.Coverage - report.Coverage
+		check := gateCheck{Name: "maximum_drop", Expected: *g.MaximumDrop, Actual: drop, Passed: drop <= *g.MaximumDrop}
+
+		if check.Passed != true {
+			check.Reason = fmt.Sprintf(
+				"coverage dropped %.2f%% (max %.2f%%) from %s", drop, check.Expected, base.SystemID,
+			)
+		}
+
+		add(check)
+	}
+
+	if g.ChangedFileMinimum == nil {
+		return result
+	}
+
+	for _, f := range changedCoverageFiles(files, changed) {
+		check := gateCheck{Name: "changed_file_minimum", Expected: *g.ChangedFileMinimum, Actual: f.Coverage}
+		check.Passed = f.Coverage >= *g.ChangedFileMinimum
+
+		if check.Passed != true {
+			check.Reason = fmt.Sprintf(
+				"%s coverage %.2f%% is below the minimum of %.2f%%", f.FileName, f.Coverage, check.Expected,
+			)
+		}
+
+		add(check)
+	}
+
+	return result
+}
+
+// changedCoverageFiles returns the coverage files of the paths changed in a diff. Coverage files are named by import
+// path while diffs list paths relative to the repository, so a changed path matches the files it is a suffix of; paths
+// without coverage (e.g. deleted or non-go files) match nothing.
+func changedCoverageFiles(files []*models.CoverageFile, changed []string) []*models.CoverageFile {
+	results := make([]*models.CoverageFile, 0, len(changed))
+
+	for _, f := range files {
+		for _, name := range changed {
+			if f.FileName == name || strings.HasSuffix(f.FileName, "/"+strings.TrimPrefix(name, "/")) {
+				results = append(results, f)
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+// parseChangedFiles returns the changed paths of the (repeated and/or comma separated) values.
+func parseChangedFiles(values []string) []string {
+	results := make([]string, 0, len(values))
+
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				results = append(results, name)
+			}
+		}
+	}
+
+	return results
+}
+
+// runQualityGate loads the project's gate along with the base report (the latest report of the base tag created
+// before the report being checked) and evaluates the report, checking the files of the changed paths against the
+// changed file minimum. The base tag may be overridden by the caller.
+func runQualityGate(
+	reports models.ReportStore, files models.CoverageFileStore, p *models.Project, r *models.Report, baseTag string,
+	changed []string,
+) (*gateResult, error) {
+	gate, e := parseQualityGate(p.QualityGate)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if baseTag == "" {
+		baseTag = gate.BaseTag
+	}
+
+	var headFiles []*models.CoverageFile
+
+	if gate.ChangedFileMinimum != nil && len(changed) > 0 {
+		headFiles, e = files.FindCoverageFiles(&models.CoverageFileBlueprint{ReportID: []string{r.SystemID}})
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	if baseTag == "" {
+		return gate.evaluate(r, nil, headFiles, changed), nil
+	}
+
+	candidates, e := reports.FindReports(&models.ReportBlueprint{
+		ProjectID:      []string{p.SystemID},
+		Tag:            []string{baseTag},
+		IDRange:        []uint{0, r.ID},
+		OrderBy:        "id",
+		OrderDirection: "DESC",
+		Limit:          1,
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(candidates) != 1 {
+		return gate.evaluate(r, nil, headFiles, changed), nil
+	}
+
+	return gate.evaluate(r, candidates[0], headFiles, changed), nil
+}
@@ -0,0 +1,144 @@
+package gendry
+
+import "testing"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_QualityGate(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("parseQualityGate", func() {
+		g.It("returns an empty gate for an empty string", func() {
+			gate, e := parseQualityGate("")
+			g.Assert(e).Equal(nil)
+			g.Assert(gate.MinimumCoverage == nil).Equal(true)
+			g.Assert(gate.String()).Equal("")
+		})
+
+		g.It("round trips through the persisted string format", func() {
+			gate, e := parseQualityGate("minimum=80,drop=1.5,base=master,changed=70")
+			g.Assert(e).Equal(nil)
+			g.Assert(*gate.MinimumCoverage).Equal(float64(80))
+			g.Assert(*gate.MaximumDrop).Equal(1.5)
+			g.Assert(gate.BaseTag).Equal("master")
+			g.Assert(*gate.ChangedFileMinimum).Equal(float64(70))
+			g.Assert(gate.String()).Equal("minimum=80,drop=1.5,base=master,changed=70")
+		})
+
+		g.It("returns an error for unknown rules", func() {
+			_, e := parseQualityGate("maximum=10")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for percentages outside of 0-100", func() {
+			_, e := parseQualityGate("minimum=110")
+			g.Assert(e == nil).Equal(false)
+		})
+	})
+
+	g.Describe("qualityGate.evaluate", func() {
+		var gate *qualityGate
+		var head, base *models.Report
+		var files []*models.CoverageFile
+
+		g.BeforeEach(func() {
+			gate, _ = parseQualityGate("minimum=50,drop=5,base=master,changed=60")
+			head = &models.Report{SystemID: "head", Coverage: 60}
+			base = &models.Report{SystemID: "base", Coverage: 62}
+			files = []*models.CoverageFile{
+				{FileName: "github.com/a/b/one.go", Statements: 2, Covered: 2, Coverage: 100},
+				{FileName: "github.com/a/b/two.go", Statements: 2, Covered: 1, Coverage: 50},
+			}
+		})
+
+		g.It("passes when every rule is satisfied", func() {
+			result := gate.evaluate(head, base, files, []string{"one.go"})
+			g.Assert(result.Passed).Equal(true)
+			g.Assert(len(result.Checks)).Equal(3)
+			g.Assert(len(result.reasons())).Equal(0)
+		})
+
+		g.It("fails when coverage is below the minimum", func() {
+			head.Coverage = 40
+			result := gate.evaluate(head, nil, files, nil)
+			g.Assert(result.Passed).Equal(false)
+			g.Assert(len(result.reasons())).Equal(1)
+		})
+
+		g.It("fails when coverage dropped more than allowed", func() {
+			base.Coverage = 70
+			result := gate.evaluate(head, base, files, nil)
+			g.Assert(result.Passed).Equal(false)
+			g.Assert(result.Checks[1].Name).Equal("maximum_drop")
+			g.Assert(result.Checks[1].Passed).Equal(false)
+		})
+
+		g.It("fails when a file changed in the diff is below the changed file minimum", func() {
+			result := gate.evaluate(head, base, files, []string{"b/two.go"})
+			g.Assert(result.Passed).Equal(false)
+			g.Assert(result.Checks[2].Name).Equal("changed_file_minimum")
+			g.Assert(result.Checks[2].Actual).Equal(float64(50))
+		})
+
+		g.It("checks files changed in the diff even when their coverage did not move", func() {
+			result := gate.evaluate(head, base, files, []string{"two.go"})
+			g.Assert(result.Passed).Equal(false)
+		})
+
+		g.It("does not check files outside of the diff, whatever their coverage", func() {
+			result := gate.evaluate(head, base, files, []string{"one.go", "README.md"})
+			g.Assert(result.Passed).Equal(true)
+			g.Assert(len(result.Checks)).Equal(3)
+		})
+
+		g.It("checks changed files without a base report", func() {
+			result := gate.evaluate(head, nil, files, []string{"two.go"})
+			g.Assert(result.Passed).Equal(false)
+			g.Assert(result.Checks[1].Name).Equal("changed_file_minimum")
+		})
+
+		g.It("skips the drop rule without a base report", func() {
+			result := gate.evaluate(head, nil, files, nil)
+			g.Assert(result.Passed).Equal(true)
+			g.Assert(len(result.Checks)).Equal(1)
+		})
+	})
+
+	g.Describe("parseChangedFiles", func() {
+		g.It("accepts repeated and comma separated paths, dropping empty ones", func() {
+			changed := parseChangedFiles([]string{"a.go, b.go", "", "c.go"})
+			g.Assert(changed).Equal([]string{"a.go", "b.go", "c.go"})
+		})
+	})
+
+	g.Describe("runQualityGate", func() {
+		var reports *testReportPersistence
+		var project *models.Project
+		var head *models.Report
+
+		g.BeforeEach(func() {
+			head = &models.Report{ID: 5, SystemID: "head", ProjectID: "project-1", Coverage: 80}
+			project = &models.Project{SystemID: "project-1", QualityGate: "drop=1,base=master"}
+			reports = &testReportPersistence{reports: []*models.Report{
+				{ID: 3, SystemID: "base", ProjectID: "project-1", Tag: "master", Coverage: 90},
+			}}
+		})
+
+		g.It("loads only the latest base report created before the report being checked", func() {
+			result, e := runQualityGate(reports, &testCoveragePersistence{}, project, head, "", nil)
+			g.Assert(e).Equal(nil)
+			g.Assert(reports.found.Limit).Equal(1)
+			g.Assert(reports.found.IDRange).Equal([]uint{0, 5})
+			g.Assert(reports.found.Tag).Equal([]string{"master"})
+			g.Assert(result.Passed).Equal(false)
+		})
+
+		g.It("skips the base report lookup without a base tag", func() {
+			project.QualityGate = "minimum=50"
+			result, e := runQualityGate(reports, &testCoveragePersistence{}, project, head, "", nil)
+			g.Assert(e).Equal(nil)
+			g.Assert(reports.found == nil).Equal(true)
+			g.Assert(result.Passed).Equal(true)
+		})
+	})
+}
@@ -3,6 +3,8 @@ package gendry
 import "io"
 import "fmt"
 import "path"
+import "time"
+import "strings"
 import "strconv"
 import "net/url"
 import "net/http"
@@ -18,28 +20,74 @@ const (
 	reportTagBodyParam        = "tag"
 	textCoverageFileExtension = ".txt"
 	htmlCoverageFileExtension = ".html"
+	reportFileDirectory       = "reports"
 	projectAPIKeyHeader       = "x-project-key"
 )
 
 // NewReportAPI returns an api for storing and retreiving reports
-func NewReportAPI(re models.ReportStore, pr models.ProjectStore, fs FileStore, log LeveledLogger) APIEndpoint {
+func NewReportAPI(
+	re models.ReportStore, pr models.ProjectStore, cf models.CoverageFileStore, fs FileStore, metrics *Metrics,
+	log LeveledLogger,
+) APIEndpoint {
 	api := &reportAPI{
-		LeveledLogger: log,
-		filestore:     fs,
-		reports:       re,
-		projects:      pr,
+		LeveledLogger:   log,
+		reportAuthority: reportAuthority{projects: pr, reports: re},
+		filestore:       fs,
+		coverageFiles:   cf,
+		cleaner:         &reportCleaner{reports: re, coverageFiles: cf, filestore: fs},
+		uploadSizes: metrics.Histogram(
+			"gendry_report_upload_bytes", "Size of the files uploaded with reports.", SizeBuckets, "file",
+		),
+		parseDurations: metrics.Histogram(
+			"gendry_coverage_parse_duration_seconds", "Time spent parsing uploaded coverage profiles.", DurationBuckets,
+		),
 	}
 
 	return api
 }
 
+// NewReportCompareAPI returns an api comparing two reports of a project; unlike the report api it only responds to GET
+// requests.
+func NewReportCompareAPI(
+	re models.ReportStore, pr models.ProjectStore, cf models.CoverageFileStore, log LeveledLogger,
+) APIEndpoint {
+	api := &reportCompareAPI{
+		reports: &reportAPI{
+			LeveledLogger:   log,
+			reportAuthority: reportAuthority{projects: pr, reports: re},
+			coverageFiles:   cf,
+		},
+	}
+
+	return api
+}
+
+// reportCompareAPI holds (rather than embeds) the report api so that none of its other methods are routed to.
+type reportCompareAPI struct {
+	reports *reportAPI
+}
+
+func (a *reportCompareAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	project, e := a.reports.project(request)
+
+	if e != nil {
+		a.reports.Warnf("unable to find project (error %s)", e.Error())
+		a.reports.renderError(writer, "invalid-project")
+		return
+	}
+
+	a.reports.compare(writer, request, project)
+}
+
 type reportAPI struct {
 	LeveledLogger
-	notImplementedRoute
 	jsonResponder
-	filestore FileStore
-	projects  models.ProjectStore
-	reports   models.ReportStore
+	reportAuthority
+	filestore      FileStore
+	coverageFiles  models.CoverageFileStore
+	cleaner        *reportCleaner
+	uploadSizes    *Histogram
+	parseDurations *Histogram
 }
 
 type reportFiles struct {
@@ -56,9 +104,14 @@ func (a *reportAPI) Get(writer http.ResponseWriter, request *http.Request, param
 		return
 	}
 
-	target := request.URL.Query().Get(constants.ProjectIDParamName)
+	query := request.URL.Query()
+	target := query.Get(constants.ProjectIDParamName)
+
+	if target == "" {
+		target = project.SystemID
+	}
 
-	paging := a.paging(request)
+	paging := parsePagingInfo(request)
 
 	blueprint := &models.ProjectBlueprint{
 		SystemID: []string{target},
@@ -87,6 +140,28 @@ func (a *reportAPI) Get(writer http.ResponseWriter, request *http.Request, param
 		Offset:    paging.offset,
 	}
 
+	if commit := query.Get(constants.ReportCommitParamName); commit != "" {
+		bp.Commit = []string{commit}
+	}
+
+	if branch := query.Get(constants.ReportBranchParamName); branch != "" {
+		bp.Branch = []string{branch}
+	}
+
+	latest := false
+
+	// Tags are moving pointers; unless the tag's history was requested, only the latest report with the tag is listed.
+	if tag := query.Get(constants.ReportTagParamName); tag != "" {
+		bp.Tag = []string{tag}
+		bp.OrderBy, bp.OrderDirection = "id", "DESC"
+
+		if query.Get(constants.ReportHistoryParamName) != "true" {
+			latest = true
+			bp.Limit, bp.Offset = 1, 0
+			paging.limit, paging.offset = 1, 0
+		}
+	}
+
 	reports, e := a.reports.FindReports(bp)
 
 	if e != nil {
@@ -103,24 +178,21 @@ func (a *reportAPI) Get(writer http.ResponseWriter, request *http.Request, param
 		return
 	}
 
+	if latest && paging.total > 1 {
+		paging.total = 1
+	}
+
 	results := make([]interface{}, len(reports))
 
 	for i, r := range reports {
-		results[i] = struct {
-			ID         uint    `json:"id"`
-			SystemID   string  `json:"system_id"`
-			HTMLFileID string  `json:"html_field_id"`
-			ProjectID  string  `json:"project_id"`
-			Tag        string  `json:"tag"`
-			Coverage   float64 `json:"coverage"`
-		}{r.ID, r.SystemID, r.HTMLFileID, r.ProjectID, r.Tag, r.Coverage}
+		results[i] = reportDetails(r)
 	}
 
 	a.renderSuccess(writer, append(results, paging)...)
 }
 
 func (a *reportAPI) Delete(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	report, e := a.authorizeLookup(request)
+	report, e := a.authorizeLookup(request, request.URL.Query().Get(constants.ReportIDParamName))
 
 	if e != nil {
 		a.Warnf("unauthorized attempt (error %v)", e)
@@ -132,8 +204,8 @@ func (a *reportAPI) Delete(writer http.ResponseWriter, request *http.Request, pa
 		SystemID: []string{report.SystemID},
 	}
 
-	if _, e := a.reports.DeleteReports(blueprint); e != nil {
-		a.Warnf("unable to delete report (error %v)", e)
+	if _, e := a.cleaner.deleteReports(blueprint); e != nil {
+		a.Warnf("unable to delete report %s (error %v)", report.SystemID, e)
 		a.renderError(writer, "server-error")
 		return
 	}
@@ -141,6 +213,97 @@ func (a *reportAPI) Delete(writer http.ResponseWriter, request *http.Request, pa
 	a.renderSuccess(writer, nil)
 }
 
+// Patch updates the tag and/or build metadata of a report; fields missing from the (url encoded) body are left as is.
+func (a *reportAPI) Patch(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	report, e := a.authorizeLookup(request, request.URL.Query().Get(constants.ReportIDParamName))
+
+	if e != nil {
+		a.Warnf("unauthorized attempt (error %v)", e)
+		a.renderError(writer, "invalid-report")
+		return
+	}
+
+	if e := request.ParseForm(); e != nil {
+		a.renderError(writer, "invalid-request")
+		return
+	}
+
+	form := request.PostForm
+	metadata, e := parseReportMetadata(form)
+
+	if e != nil {
+		a.Warnf("invalid report metadata for report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, e.Error())
+		return
+	}
+
+	if _, ok := form[reportTagBodyParam]; ok && form.Get(reportTagBodyParam) == "" {
+		a.renderError(writer, "invalid-tag")
+		return
+	}
+
+	blueprint := &models.ReportBlueprint{
+		SystemID: []string{report.SystemID},
+	}
+
+	builtAt := time.Time{}
+
+	if metadata.BuiltAt != nil {
+		builtAt = *metadata.BuiltAt
+	}
+
+	// Fields are updated in a fixed order so a failure always leaves the same fields (those before it) updated.
+	updates := []struct {
+		param  string
+		update func() (int64, error, string)
+	}{
+		{reportTagBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportTag(form.Get(reportTagBodyParam), blueprint)
+		}},
+		{constants.ReportCommitBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportCommit(metadata.Commit, blueprint)
+		}},
+		{constants.ReportBranchBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportBranch(metadata.Branch, blueprint)
+		}},
+		{constants.ReportPullRequestBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportPullRequest(metadata.PullRequest, blueprint)
+		}},
+		{constants.ReportBuildURLBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportBuildURL(metadata.BuildURL, blueprint)
+		}},
+		{constants.ReportAuthorBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportAuthor(metadata.Author, blueprint)
+		}},
+		{constants.ReportTimestampBodyParam, func() (int64, error, string) {
+			return a.reports.UpdateReportBuiltAt(builtAt, blueprint)
+		}},
+	}
+
+	for _, field := range updates {
+		if _, ok := form[field.param]; ok != true {
+			continue
+		}
+
+		if _, e, _ := field.update(); e != nil {
+			a.Errorf("unable to update %s of report %s (error %v)", field.param, report.SystemID, e)
+			a.renderError(writer, "server-error")
+			return
+		}
+	}
+
+	updated, e := a.reports.FindReports(blueprint)
+
+	if e != nil || len(updated) != 1 {
+		a.Errorf("unable to reload report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	a.Infof("updated report %s", report.SystemID)
+	a.renderSuccess(writer, reportDetails(updated[0]))
+}
+
 func (a *reportAPI) Post(writer http.ResponseWriter, request *http.Request, params url.Values) {
 	project, e := a.project(request)
 
@@ -168,6 +331,14 @@ func (a *reportAPI) Post(writer http.ResponseWriter, request *http.Request, para
 		return
 	}
 
+	metadata, e := parseReportMetadata(request.Form)
+
+	if e != nil {
+		a.Warnf("invalid report metadata for project %s (error %v)", projectID, e)
+		a.renderError(writer, e.Error())
+		return
+	}
+
 	reports, e := a.parseReportForm(request.MultipartForm)
 
 	if e != nil {
@@ -190,14 +361,25 @@ func (a *reportAPI) Post(writer http.ResponseWriter, request *http.Request, para
 		Coverage:   reports.coverage.coverage,
 		ProjectID:  project.SystemID,
 		Tag:        tag,
+		CreatedAt:  time.Now(),
 	}
 
+	metadata.apply(&record)
+
 	if _, e := a.reports.CreateReports(record); e != nil {
 		a.Errorf("unable to save report: %s", e.Error())
+		a.filestore.DeleteFile(path.Join(reportFileDirectory, fileID))
 		a.renderError(writer, e.Error())
 		return
 	}
 
+	if _, e := a.coverageFiles.CreateCoverageFiles(reports.coverage.coverageFiles(record.SystemID)...); e != nil {
+		a.Errorf("unable to save file coverage for report %s: %s", record.SystemID, e.Error())
+		a.cleaner.deleteReports(&models.ReportBlueprint{SystemID: []string{record.SystemID}})
+		a.renderError(writer, "server-error")
+		return
+	}
+
 	primaryIDs, e := a.reports.SelectIDs(&models.ReportBlueprint{SystemID: []string{record.SystemID}})
 
 	if e != nil {
@@ -206,76 +388,112 @@ func (a *reportAPI) Post(writer http.ResponseWriter, request *http.Request, para
 	}
 
 	a.Infof("successfully created report (id %s) - coverage %f", record.SystemID, record.Coverage)
+	record.ID = primaryIDs[0]
 
-	a.renderSuccess(writer, struct {
-		ID         uint    `json:"id"`
-		SystemID   string  `json:"system_id"`
-		Tag        string  `json:"tag"`
-		HTMLFileID string  `json:"html_file_id"`
-		Coverage   float64 `json:"coverage"`
-		ProjectID  string  `json:"project_id"`
-	}{primaryIDs[0], record.SystemID, record.Tag, record.HTMLFileID, record.Coverage, record.ProjectID})
-}
+	var gate *gateResult
 
-func (a *reportAPI) project(request *http.Request) (*models.Project, error) {
-	token := request.Header.Get(constants.ProjectAuthTokenAPIHeader)
-	projects, e := a.projects.FindProjects(&models.ProjectBlueprint{Token: []string{token}})
+	if request.Form.Get(constants.ReportGateBodyParam) == "true" {
+		changed := parseChangedFiles(request.Form[constants.ReportChangedFilesBodyParam])
+		gate, e = runQualityGate(a.reports, a.coverageFiles, project, &record, "", changed)
 
-	if e != nil {
-		return nil, e
+		if e != nil {
+			a.Warnf("unable to run quality gate for report %s (error %v)", record.SystemID, e)
+			a.renderError(writer, "server-error")
+			return
+		}
 	}
 
-	if len(projects) != 1 {
-		return nil, fmt.Errorf("invalid-token")
+	result := struct {
+		ID         uint        `json:"id"`
+		SystemID   string      `json:"system_id"`
+		Tag        string      `json:"tag"`
+		HTMLFileID string      `json:"html_file_id"`
+		Coverage   float64     `json:"coverage"`
+		ProjectID  string      `json:"project_id"`
+		CreatedAt  time.Time   `json:"created_at"`
+		Gate       *gateResult `json:"gate,omitempty"`
+		reportMetadata
+	}{
+		record.ID, record.SystemID, record.Tag, record.HTMLFileID, record.Coverage, record.ProjectID,
+		record.CreatedAt, gate, *metadata,
 	}
 
-	return projects[0], nil
+	if gate != nil && gate.Passed != true {
+		a.renderFailure(writer, gate.reasons(), result)
+		return
+	}
+
+	a.renderSuccess(writer, result)
 }
 
-func (a *reportAPI) authorizeLookup(request *http.Request) (*models.Report, error) {
-	project, e := a.project(request)
+func (a *reportAPI) compare(writer http.ResponseWriter, request *http.Request, project *models.Project) {
+	query := request.URL.Query()
+	base, e := a.resolveReport(project, query.Get(constants.CompareBaseParamName))
 
 	if e != nil {
-		return nil, e
+		a.Warnf("unable to resolve base report %s (error %v)", query.Get(constants.CompareBaseParamName), e)
+		a.renderError(writer, "invalid-base")
+		return
 	}
 
-	id := request.URL.Query().Get(constants.ReportIDParamName)
+	head, e := a.resolveReport(project, query.Get(constants.CompareHeadParamName))
 
-	blueprint := &models.ReportBlueprint{
-		SystemID: []string{id},
+	if e != nil {
+		a.Warnf("unable to resolve head report %s (error %v)", query.Get(constants.CompareHeadParamName), e)
+		a.renderError(writer, "invalid-head")
+		return
 	}
 
-	if internal, e := strconv.Atoi(id); e == nil {
-		blueprint.ID = []uint{uint(internal)}
-		blueprint.Inclusive = true
+	baseFiles, e := a.coverageFiles.FindCoverageFiles(&models.CoverageFileBlueprint{ReportID: []string{base.SystemID}})
+
+	if e != nil {
+		a.Warnf("unable to load coverage files for report %s (error %v)", base.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
 	}
 
-	reports, e := a.reports.FindReports(blueprint)
+	headFiles, e := a.coverageFiles.FindCoverageFiles(&models.CoverageFileBlueprint{ReportID: []string{head.SystemID}})
 
-	if len(reports) != 1 || e != nil {
-		return nil, fmt.Errorf("invalid-report")
+	if e != nil {
+		a.Warnf("unable to load coverage files for report %s (error %v)", head.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
 	}
 
-	if reports[0].ProjectID != project.SystemID {
-		return nil, fmt.Errorf("unauthorized")
+	comparison, e := compareReports(base, head, baseFiles, headFiles)
+
+	if e != nil {
+		a.Warnf("unable to compare reports %s and %s (error %v)", base.SystemID, head.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
 	}
 
-	return reports[0], nil
+	a.renderSuccess(writer, comparison)
 }
 
+// writeReportHTMLFile stores the uploaded html file as is, deleting it again if it could not be stored entirely.
 func (a *reportAPI) writeReportHTMLFile(source *multipart.FileHeader) (string, error) {
-	id, file, e := a.filestore.NewFile("text/html", "reports")
+	if source.Size > 0 != true {
+		return "", fmt.Errorf("no-upload")
+	}
+
+	id, file, e := a.filestore.NewFile("text/html", reportFileDirectory)
 
 	if e != nil {
 		return "", e
 	}
 
-	defer file.Close()
+	// Closing the file is what persists it; the report must not be created if the file could not be stored.
+	discard := func(e error) (string, error) {
+		file.Close()
+		a.filestore.DeleteFile(path.Join(reportFileDirectory, id))
+		return "", e
+	}
 
 	reader, e := source.Open()
 
 	if e != nil {
-		return "", e
+		return discard(e)
 	}
 
 	defer reader.Close()
@@ -283,11 +501,16 @@ func (a *reportAPI) writeReportHTMLFile(source *multipart.FileHeader) (string, e
 	size, e := io.Copy(file, reader)
 
 	if e != nil {
-		return "", e
+		return discard(e)
 	}
 
 	if size > 0 != true {
-		return "", fmt.Errorf("no-upload")
+		return discard(fmt.Errorf("no-upload"))
+	}
+
+	if e := file.Close(); e != nil {
+		a.filestore.DeleteFile(path.Join(reportFileDirectory, id))
+		return "", e
 	}
 
 	return id, nil
@@ -305,6 +528,8 @@ func (a *reportAPI) parseReportForm(form *multipart.Form) (*reportFiles, error)
 			continue
 		}
 
+		a.uploadSizes.Observe(float64(f.Size), strings.TrimPrefix(ext, "."))
+
 		if ext == ".html" {
 			result.html = f
 			continue
@@ -318,7 +543,9 @@ func (a *reportAPI) parseReportForm(form *multipart.Form) (*reportFiles, error)
 		}
 
 		defer coverage.Close()
+		start := time.Now()
 		result.coverage, e = parseCoverProfile(coverage)
+		a.parseDurations.Since(start)
 
 		if e != nil {
 			a.Warnf("unable to open coverage file during report creation: %s", e.Error())
@@ -333,16 +560,16 @@ func (a *reportAPI) parseReportForm(form *multipart.Form) (*reportFiles, error)
 	return result, nil
 }
 
-func (a *reportAPI) paging(request *http.Request) pagingInfo {
-	paging := pagingInfo{limit: 10, offset: 0}
-
-	if offset, e := strconv.Atoi(request.URL.Query().Get(constants.OffsetParamName)); e == nil {
-		paging.offset = offset
-	}
-
-	if limit, e := strconv.Atoi(request.URL.Query().Get(constants.LimitParamName)); e == nil {
-		paging.limit = limit
-	}
-
-	return paging
+// reportDetails returns the publicly visible fields of a report record.
+func reportDetails(r *models.Report) interface{} {
+	return struct {
+		ID         uint      `json:"id"`
+		SystemID   string    `json:"system_id"`
+		HTMLFileID string    `json:"html_field_id"`
+		ProjectID  string    `json:"project_id"`
+		Tag        string    `json:"tag"`
+		Coverage   float64   `json:"coverage"`
+		CreatedAt  time.Time `json:"created_at"`
+		reportMetadata
+	}{r.ID, r.SystemID, r.HTMLFileID, r.ProjectID, r.Tag, r.Coverage, r.CreatedAt, metadataOf(r)}
 }
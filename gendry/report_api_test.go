@@ -1,11 +1,204 @@
 package gendry
 
+import "io"
+import "bytes"
+import "encoding/json"
+import "io/ioutil"
+import "strings"
 import "testing"
+import "net/url"
+import "mime/multipart"
+import "net/http/httptest"
 import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// testFileHeader returns the header of a file uploaded in a multipart form under the report files param.
+func testFileHeader(name string, content string) *multipart.FileHeader {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile(reportFileBodyParam, name)
+	io.WriteString(part, content)
+	writer.Close()
+
+	form, _ := multipart.NewReader(body, writer.Boundary()).ReadForm(maxReportFileSize)
+	return form.File[reportFileBodyParam][0]
+}
+
+// testDeletingStore records the files deleted from the memory store.
+type testDeletingStore struct {
+	*memorystore
+	deleted []string
+}
+
+func (s *testDeletingStore) DeleteFile(name string) error {
+	s.deleted = append(s.deleted, name)
+	return s.memorystore.DeleteFile(name)
+}
 
 func Test_ReportAPI(t *testing.T) {
 	g := goblin.Goblin(t)
 
 	g.Describe("ReportAPI", func() {
+		var api *reportAPI
+		var store *memorystore
+
+		g.BeforeEach(func() {
+			store = newMemoryStore(0, 0)
+			api = &reportAPI{LeveledLogger: &testLogger{}, filestore: store}
+		})
+
+		g.Describe("Get", func() {
+			var recorder *httptest.ResponseRecorder
+
+			g.BeforeEach(func() {
+				recorder = httptest.NewRecorder()
+				api.reportAuthority = reportAuthority{
+					projects: &testProjectPersistence{projects: []*models.Project{
+						{SystemID: "project-1", Token: "secret"},
+					}},
+					reports: &testReportPersistence{reports: []*models.Report{
+						{ID: 1, SystemID: "report-1", ProjectID: "project-1", Tag: "master"},
+						{ID: 2, SystemID: "report-2", ProjectID: "project-1", Tag: "master"},
+						{ID: 3, SystemID: "report-3", ProjectID: "project-1", Tag: "develop"},
+					}},
+				}
+			})
+
+			list := func(query string) ([]string, int) {
+				request := httptest.NewRequest("GET", "/reports?"+query, nil)
+				request.Header.Set(constants.ProjectAuthTokenAPIHeader, "secret")
+				api.Get(recorder, request, nil)
+
+				response := struct {
+					Meta struct {
+						Total int `json:"total"`
+					} `json:"meta"`
+					Data []struct {
+						SystemID string `json:"system_id"`
+					} `json:"data"`
+				}{}
+
+				json.NewDecoder(recorder.Body).Decode(&response)
+				ids := make([]string, len(response.Data))
+
+				for i, r := range response.Data {
+					ids[i] = r.SystemID
+				}
+
+				return ids, response.Meta.Total
+			}
+
+			g.It("lists only the latest report of a tag", func() {
+				ids, total := list("tag=master")
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(ids).Equal([]string{"report-2"})
+				g.Assert(total).Equal(1)
+			})
+
+			g.It("lists every report of a tag, latest first, when the history is requested", func() {
+				ids, total := list("tag=master&history=true")
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(ids).Equal([]string{"report-2", "report-1"})
+				g.Assert(total).Equal(2)
+			})
+
+			g.It("reports a total of zero for tags without reports", func() {
+				ids, total := list("tag=missing")
+				g.Assert(len(ids)).Equal(0)
+				g.Assert(total).Equal(0)
+			})
+		})
+
+		g.Describe("Patch", func() {
+			var reports *testReportPersistence
+			var report *models.Report
+			var recorder *httptest.ResponseRecorder
+
+			g.BeforeEach(func() {
+				report = &models.Report{SystemID: "report-1", ProjectID: "project-1", Tag: "v1", Branch: "main"}
+				reports = &testReportPersistence{reports: []*models.Report{report}}
+				recorder = httptest.NewRecorder()
+
+				api.reportAuthority = reportAuthority{
+					reports: reports,
+					projects: &testProjectPersistence{projects: []*models.Project{
+						{SystemID: "project-1", Token: "secret"},
+						{SystemID: "project-2", Token: "other"},
+					}},
+				}
+			})
+
+			patch := func(token string, form url.Values) {
+				request := httptest.NewRequest("PATCH", "/reports?report_id=report-1", strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				request.Header.Set(constants.ProjectAuthTokenAPIHeader, token)
+				api.Patch(recorder, request, nil)
+			}
+
+			g.It("rejects tokens of projects that do not own the report", func() {
+				patch("other", url.Values{"tag": {"v2"}})
+				g.Assert(recorder.Code).Equal(422)
+				g.Assert(strings.Contains(recorder.Body.String(), "invalid-report")).Equal(true)
+				g.Assert(report.Tag).Equal("v1")
+			})
+
+			g.It("only updates the fields present in the body", func() {
+				patch("secret", url.Values{"commit": {"abc123"}})
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(report.Commit).Equal("abc123")
+				g.Assert(report.Tag).Equal("v1")
+				g.Assert(report.Branch).Equal("main")
+				g.Assert(reports.updated).Equal([]string{"commit"})
+			})
+
+			g.It("updates the fields in a fixed order", func() {
+				patch("secret", url.Values{"author": {"dan"}, "branch": {"dev"}, "tag": {"v2"}, "commit": {"abc"}})
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(reports.updated).Equal([]string{"tag", "commit", "branch", "author"})
+				g.Assert(report.Tag).Equal("v2")
+			})
+
+			g.It("rejects an empty tag", func() {
+				patch("secret", url.Values{"tag": {""}})
+				g.Assert(recorder.Code).Equal(422)
+				g.Assert(strings.Contains(recorder.Body.String(), "invalid-tag")).Equal(true)
+				g.Assert(report.Tag).Equal("v1")
+			})
+
+			g.It("rejects invalid metadata without updating any field", func() {
+				patch("secret", url.Values{"branch": {"dev"}, "pull_request": {"twelve"}})
+				g.Assert(recorder.Code).Equal(422)
+				g.Assert(strings.Contains(recorder.Body.String(), "invalid-pull-request")).Equal(true)
+				g.Assert(len(reports.updated)).Equal(0)
+				g.Assert(report.Branch).Equal("main")
+			})
+		})
+
+		g.Describe("writeReportHTMLFile", func() {
+			g.It("stores the uploaded html file unchanged", func() {
+				id, e := api.writeReportHTMLFile(testFileHeader("index.html", "<html><body>ok</body></html>"))
+				g.Assert(e).Equal(nil)
+				file, e := store.FindFile(reportFileDirectory + "/" + id)
+				g.Assert(e).Equal(nil)
+				content, _ := ioutil.ReadAll(file)
+				g.Assert(string(content)).Equal("<html><body>ok</body></html>")
+			})
+
+			g.It("returns an error, deleting the file, when the file can not be stored", func() {
+				deleting := &testDeletingStore{memorystore: store}
+				api.filestore = deleting
+				store.maxBytes = 4
+				_, e := api.writeReportHTMLFile(testFileHeader("index.html", "<html></html>"))
+				g.Assert(e == nil).Equal(false)
+				g.Assert(len(deleting.deleted)).Equal(1)
+			})
+
+			g.It("returns an error without storing a file when the upload is empty", func() {
+				_, e := api.writeReportHTMLFile(testFileHeader("index.html", ""))
+				g.Assert(e == nil).Equal(false)
+				g.Assert(len(store.files)).Equal(0)
+			})
+		})
 	})
 }
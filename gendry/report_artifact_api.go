@@ -0,0 +1,100 @@
+package gendry
+
+import "io"
+import "path"
+import "time"
+import "net/url"
+import "net/http"
+import "io/ioutil"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// NewReportArtifactAPI returns an api that describes the stored html file of a report, optionally verifying its
+// content against the recorded checksum.
+func NewReportArtifactAPI(
+	re models.ReportStore, pr models.ProjectStore, fr models.FileStore, fs FileStore, log LeveledLogger,
+) APIEndpoint {
+	api := &reportArtifactAPI{
+		LeveledLogger:   log,
+		reportAuthority: reportAuthority{projects: pr, reports: re},
+		records:         fr,
+		filestore:       fs,
+	}
+
+	return api
+}
+
+type reportArtifactAPI struct {
+	LeveledLogger
+	jsonResponder
+	reportAuthority
+	records   models.FileStore
+	filestore FileStore
+}
+
+func (a *reportArtifactAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	report, e := a.authorizeLookup(request, params.Get(constants.ReportIDParamName))
+
+	if e != nil {
+		a.Warnf("unauthorized attempt (error %v)", e)
+		a.renderError(writer, "invalid-report")
+		return
+	}
+
+	record := findFileRecord(a.records, report.HTMLFileID)
+
+	if record == nil {
+		a.renderError(writer, "not-found")
+		return
+	}
+
+	result := struct {
+		SystemID    string    `json:"system_id"`
+		Status      string    `json:"status"`
+		ContentType string    `json:"content_type"`
+		Size        int64     `json:"size"`
+		Checksum    string    `json:"checksum"`
+		ObjectID    string    `json:"object_id"`
+		Encoding    string    `json:"encoding,omitempty"`
+		CreatedAt   time.Time `json:"created_at"`
+		Verified    *bool     `json:"verified,omitempty"`
+	}{
+		record.SystemID, record.Status, record.ContentType, record.Size, record.Checksum, objectOf(record),
+		record.Encoding, record.CreatedAt, nil,
+	}
+
+	if request.URL.Query().Get(constants.ArtifactVerifyParamName) != "true" || record.Checksum == "" {
+		a.renderSuccess(writer, result)
+		return
+	}
+
+	verified, e := a.verify(record)
+
+	if e != nil {
+		a.Warnf("unable to verify file %s of report %s (error %v)", record.SystemID, report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	result.Verified = &verified
+	a.renderSuccess(writer, result)
+}
+
+// verify reads the entire file, returning whether its content matched the checksum and size of its record.
+func (a *reportArtifactAPI) verify(record *models.File) (bool, error) {
+	reader, e := a.filestore.FindFile(path.Join(reportFileDirectory, record.SystemID))
+
+	if e != nil {
+		return false, e
+	}
+
+	defer reader.Close()
+
+	_, e = io.Copy(ioutil.Discard, reader)
+
+	if e == ErrChecksumMismatch {
+		return false, nil
+	}
+
+	return e == nil, e
+}
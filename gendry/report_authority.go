@@ -0,0 +1,101 @@
+package gendry
+
+import "fmt"
+import "strconv"
+import "net/http"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// reportAuthority is embedded by apis that authenticate project tokens and look up reports owned by those projects.
+type reportAuthority struct {
+	projects models.ProjectStore
+	reports  models.ReportStore
+}
+
+func (a *reportAuthority) project(request *http.Request) (*models.Project, error) {
+	token := request.Header.Get(constants.ProjectAuthTokenAPIHeader)
+	projects, e := a.projects.FindProjects(&models.ProjectBlueprint{Token: []string{token}})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(projects) != 1 {
+		return nil, fmt.Errorf("invalid-token")
+	}
+
+	return projects[0], nil
+}
+
+// authorizeLookup returns the report identified by the id (either system or internal) if it belongs to the project
+// that has been authenticated by the request's token header.
+func (a *reportAuthority) authorizeLookup(request *http.Request, id string) (*models.Report, error) {
+	project, e := a.project(request)
+
+	if e != nil {
+		return nil, e
+	}
+
+	blueprint := &models.ReportBlueprint{
+		SystemID: []string{id},
+	}
+
+	if internal, e := strconv.Atoi(id); e == nil {
+		blueprint.ID = []uint{uint(internal)}
+		blueprint.Inclusive = true
+	}
+
+	reports, e := a.reports.FindReports(blueprint)
+
+	if len(reports) != 1 || e != nil {
+		return nil, fmt.Errorf("invalid-report")
+	}
+
+	if reports[0].ProjectID != project.SystemID {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	return reports[0], nil
+}
+
+// resolveReport returns the report belonging to the project identified by the reference, which may either be a report
+// id (system or internal) or a tag. Tags resolve to the most recently created report with that tag.
+func (a *reportAuthority) resolveReport(project *models.Project, reference string) (*models.Report, error) {
+	blueprint := &models.ReportBlueprint{
+		ProjectID: []string{project.SystemID},
+		SystemID:  []string{reference},
+	}
+
+	if internal, e := strconv.Atoi(reference); e == nil {
+		blueprint.SystemID = nil
+		blueprint.ID = []uint{uint(internal)}
+	}
+
+	if reports, e := a.reports.FindReports(blueprint); e == nil && len(reports) == 1 {
+		return reports[0], nil
+	}
+
+	return a.latestReport(project.SystemID, reference)
+}
+
+// latestReport returns the most recently created report of the project with the given tag; tags act as pointers that
+// move forward with every upload while previous reports are kept as the tag's history.
+func (a *reportAuthority) latestReport(projectID string, tag string) (*models.Report, error) {
+	reports, e := a.reports.FindReports(&models.ReportBlueprint{
+		ProjectID:      []string{projectID},
+		Tag:            []string{tag},
+		OrderBy:        "id",
+		OrderDirection: "DESC",
+		Limit:          1,
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(reports) != 1 {
+		return nil, fmt.Errorf("invalid-report")
+	}
+
+	return reports[0], nil
+}
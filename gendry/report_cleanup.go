@@ -0,0 +1,42 @@
+package gendry
+
+import "path"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// reportCleaner removes reports along with everything stored for them: their html file (and its file record) from the
+// file store and their per-file coverage records.
+type reportCleaner struct {
+	reports       models.ReportStore
+	coverageFiles models.CoverageFileStore
+	filestore     FileStore
+}
+
+// deleteReports removes every report matching the blueprint, returning the amount removed. Each report's html file is
+// removed before its database records so a failed cleanup can be retried without losing track of stored files.
+func (c *reportCleaner) deleteReports(blueprint *models.ReportBlueprint) (int, error) {
+	reports, e := c.reports.FindReports(blueprint)
+
+	if e != nil {
+		return 0, e
+	}
+
+	for i, r := range reports {
+		if r.HTMLFileID != "" {
+			if e := c.filestore.DeleteFile(path.Join(reportFileDirectory, r.HTMLFileID)); e != nil {
+				return i, e
+			}
+		}
+
+		files := &models.CoverageFileBlueprint{ReportID: []string{r.SystemID}}
+
+		if _, e := c.coverageFiles.DeleteCoverageFiles(files); e != nil {
+			return i, e
+		}
+
+		if _, e := c.reports.DeleteReports(&models.ReportBlueprint{SystemID: []string{r.SystemID}}); e != nil {
+			return i, e
+		}
+	}
+
+	return len(reports), nil
+}
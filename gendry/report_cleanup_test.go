@@ -0,0 +1,48 @@
+package gendry
+
+import "io"
+import "path"
+import "testing"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_ReportCleaner(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("reportCleaner", func() {
+		var cleaner *reportCleaner
+		var reports *testReportPersistence
+		var coverage *testCoveragePersistence
+		var files *memorystore
+
+		write := func() string {
+			id, writer, _ := files.NewFile("text/html", reportFileDirectory)
+			io.WriteString(writer, "<html></html>")
+			writer.Close()
+			return id
+		}
+
+		g.BeforeEach(func() {
+			files = newMemoryStore(0, 0)
+			coverage = &testCoveragePersistence{}
+			reports = &testReportPersistence{reports: []*models.Report{
+				{SystemID: "a", ProjectID: "gendry", HTMLFileID: write()},
+				{SystemID: "b", ProjectID: "gendry", HTMLFileID: write()},
+				{SystemID: "c", ProjectID: "other", HTMLFileID: write()},
+			}}
+			cleaner = &reportCleaner{reports: reports, coverageFiles: coverage, filestore: files}
+		})
+
+		g.It("removes the reports matching the blueprint along with their files", func() {
+			removed, e := cleaner.deleteReports(&models.ReportBlueprint{ProjectID: []string{"gendry"}})
+			g.Assert(e).Equal(nil)
+			g.Assert(removed).Equal(2)
+			g.Assert(len(reports.reports)).Equal(1)
+			g.Assert(coverage.deleted).Equal([]string{"a", "b"})
+			g.Assert(files.recent.Len()).Equal(1)
+
+			_, e = files.FindFile(path.Join(reportFileDirectory, reports.reports[0].HTMLFileID))
+			g.Assert(e).Equal(nil)
+		})
+	})
+}
@@ -0,0 +1,194 @@
+package gendry
+
+import "sort"
+import "net/url"
+import "net/http"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+var coverageFileSortColumns = map[string][2]string{
+	"coverage":  {"coverage", "ASC"},
+	"-coverage": {"coverage", "DESC"},
+	"name":      {"file_name", "ASC"},
+	"-name":     {"file_name", "DESC"},
+}
+
+type coverageBlock struct {
+	StartLine int `json:"start_line"`
+	StartCol  int `json:"start_column"`
+	EndLine   int `json:"end_line"`
+	EndCol    int `json:"end_column"`
+	NumStmt   int `json:"statements"`
+	Count     int `json:"count"`
+}
+
+// NewReportCoverageAPI returns an api that is able to render the per-file and per-package coverage of a report, as
+// well as the outcome of running the report through its project's quality gate.
+func NewReportCoverageAPI(
+	re models.ReportStore, pr models.ProjectStore, cf models.CoverageFileStore, log LeveledLogger,
+) APIEndpoint {
+	api := &reportCoverageAPI{
+		LeveledLogger:   log,
+		reportAuthority: reportAuthority{projects: pr, reports: re},
+		coverageFiles:   cf,
+	}
+
+	return api
+}
+
+type reportCoverageAPI struct {
+	LeveledLogger
+	jsonResponder
+	reportAuthority
+	coverageFiles models.CoverageFileStore
+}
+
+func (a *reportCoverageAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	report, e := a.authorizeLookup(request, params.Get(constants.ReportIDParamName))
+
+	if e != nil {
+		a.Warnf("unauthorized attempt (error %v)", e)
+		a.renderError(writer, "invalid-report")
+		return
+	}
+
+	switch params.Get("resource") {
+	case "files":
+		a.renderFiles(writer, request, report)
+	case "packages":
+		a.renderPackages(writer, request, report)
+	case "gate":
+		a.renderGate(writer, request, report)
+	default:
+		a.renderError(writer, "not-found")
+	}
+}
+
+func (a *reportCoverageAPI) renderFiles(writer http.ResponseWriter, request *http.Request, report *models.Report) {
+	query := request.URL.Query()
+	paging := parsePagingInfo(request)
+
+	blueprint := &models.CoverageFileBlueprint{
+		ReportID:       []string{report.SystemID},
+		Limit:          paging.limit,
+		Offset:         paging.offset,
+		OrderBy:        "coverage",
+		OrderDirection: "ASC",
+	}
+
+	if name := query.Get(constants.CoverageFileParamName); name != "" {
+		blueprint.FileName = []string{name}
+	}
+
+	if sort, ok := coverageFileSortColumns[query.Get(constants.CoverageSortParamName)]; ok {
+		blueprint.OrderBy, blueprint.OrderDirection = sort[0], sort[1]
+	}
+
+	files, e := a.coverageFiles.FindCoverageFiles(blueprint)
+
+	if e != nil {
+		a.Warnf("unable to find coverage files for report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	paging.total, e = a.coverageFiles.CountCoverageFiles(blueprint)
+
+	if e != nil {
+		a.Warnf("unable to count coverage files for report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	includeBlocks := query.Get(constants.CoverageBlocksParamName) == "true"
+	results := make([]interface{}, len(files))
+
+	for i, f := range files {
+		var blocks []coverageBlock
+
+		if includeBlocks {
+			decoded, e := decodeBlocks(f.Blocks)
+
+			if e != nil {
+				a.Warnf("invalid blocks for coverage file %d (error %v)", f.ID, e)
+				a.renderError(writer, "server-error")
+				return
+			}
+
+			blocks = make([]coverageBlock, len(decoded))
+
+			for j, b := range decoded {
+				blocks[j] = coverageBlock(b)
+			}
+		}
+
+		results[i] = struct {
+			FileName   string          `json:"file_name"`
+			Statements int             `json:"statements"`
+			Covered    int             `json:"covered"`
+			Coverage   float64         `json:"coverage"`
+			Blocks     []coverageBlock `json:"blocks,omitempty"`
+		}{f.FileName, f.Statements, f.Covered, f.Coverage, blocks}
+	}
+
+	a.renderSuccess(writer, append(results, paging)...)
+}
+
+func (a *reportCoverageAPI) renderPackages(writer http.ResponseWriter, request *http.Request, report *models.Report) {
+	files, e := a.coverageFiles.FindCoverageFiles(&models.CoverageFileBlueprint{
+		ReportID: []string{report.SystemID},
+	})
+
+	if e != nil {
+		a.Warnf("unable to find coverage files for report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	packages := aggregatePackages(files)
+
+	switch request.URL.Query().Get(constants.CoverageSortParamName) {
+	case "coverage":
+		sort.SliceStable(packages, func(i, j int) bool { return packages[i].Coverage < packages[j].Coverage })
+	case "-coverage":
+		sort.SliceStable(packages, func(i, j int) bool { return packages[i].Coverage > packages[j].Coverage })
+	case "-name":
+		sort.SliceStable(packages, func(i, j int) bool { return packages[i].Name > packages[j].Name })
+	}
+
+	results := make([]interface{}, len(packages))
+
+	for i, p := range packages {
+		results[i] = p
+	}
+
+	a.renderSuccess(writer, results...)
+}
+
+func (a *reportCoverageAPI) renderGate(writer http.ResponseWriter, request *http.Request, report *models.Report) {
+	project, e := a.project(request)
+
+	if e != nil {
+		a.Warnf("unable to find project (error %v)", e)
+		a.renderError(writer, "invalid-project")
+		return
+	}
+
+	query := request.URL.Query()
+	base := query.Get(constants.GateBaseParamName)
+	changed := parseChangedFiles(query[constants.GateChangedFilesParamName])
+	result, e := runQualityGate(a.reports, a.coverageFiles, project, report, base, changed)
+
+	if e != nil {
+		a.Warnf("unable to run quality gate for report %s (error %v)", report.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	if result.Passed != true {
+		a.renderFailure(writer, result.reasons(), result)
+		return
+	}
+
+	a.renderSuccess(writer, result)
+}
@@ -0,0 +1,39 @@
+package gendry
+
+import "io"
+import "fmt"
+import "bytes"
+import "regexp"
+import "strings"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// reportHeaderSearchBytes is the amount of html read while looking for the opening body tag.
+const reportHeaderSearchBytes = 64 << 10
+
+var reportBodyTagRe = regexp.MustCompile(`(?i)<body[^>]*>`)
+
+// reportHeader returns the html element rendered at the top of a report, colored by the band the coverage falls in.
+func reportHeader(coverage float64, thresholds coverageThresholds) string {
+	value := fmt.Sprintf(constants.ShieldValueTemplate, coverage)
+	return fmt.Sprintf(constants.ReportHeaderTemplate, badgeColor(thresholds.color(coverage)), value)
+}
+
+// withReportHeader returns a reader of the html that inserts the header right after the opening body tag, or before
+// the document when no body tag is found near its start.
+func withReportHeader(html io.Reader, header string) (io.Reader, error) {
+	head := make([]byte, reportHeaderSearchBytes)
+	amount, e := io.ReadFull(html, head)
+
+	if e != nil && e != io.EOF && e != io.ErrUnexpectedEOF {
+		return nil, e
+	}
+
+	head, offset := head[:amount], 0
+
+	if match := reportBodyTagRe.FindIndex(head); match != nil {
+		offset = match[1]
+	}
+
+	before, after := bytes.NewReader(head[:offset]), bytes.NewReader(head[offset:])
+	return io.MultiReader(before, strings.NewReader(header), after, html), nil
+}
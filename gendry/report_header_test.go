@@ -0,0 +1,41 @@
+package gendry
+
+import "strings"
+import "testing"
+import "io/ioutil"
+import "github.com/franela/goblin"
+
+func Test_ReportHeader(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("reportHeader", func() {
+		g.It("colors the header with the band the coverage falls in", func() {
+			header := reportHeader(60, coverageThresholds{{Minimum: 0, Color: "red"}, {Minimum: 50, Color: "orange"}})
+			g.Assert(strings.Contains(header, badgeColor("orange"))).Equal(true)
+			g.Assert(strings.Contains(header, "60.00%")).Equal(true)
+		})
+	})
+
+	g.Describe("withReportHeader", func() {
+		read := func(html string) string {
+			reader, e := withReportHeader(strings.NewReader(html), "<h1>header</h1>")
+			g.Assert(e).Equal(nil)
+			content, _ := ioutil.ReadAll(reader)
+			return string(content)
+		}
+
+		g.It("inserts the header after the opening body tag, regardless of its case and attributes", func() {
+			html := read(`<html><BODY class="report">ok</BODY></html>`)
+			g.Assert(html).Equal(`<html><BODY class="report"><h1>header</h1>ok</BODY></html>`)
+		})
+
+		g.It("inserts the header before the document when it has no body tag", func() {
+			g.Assert(read("<p>ok</p>")).Equal("<h1>header</h1><p>ok</p>")
+		})
+
+		g.It("keeps the content beyond the searched bytes", func() {
+			html := "<body>" + strings.Repeat("a", reportHeaderSearchBytes)
+			g.Assert(read(html)).Equal("<body><h1>header</h1>" + strings.Repeat("a", reportHeaderSearchBytes))
+		})
+	})
+}
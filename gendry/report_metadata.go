@@ -0,0 +1,96 @@
+package gendry
+
+import "fmt"
+import "time"
+import "strconv"
+import "net/url"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+// reportMetadata holds the optional ci build information that can be uploaded alongside a report.
+type reportMetadata struct {
+	Commit      string     `json:"commit"`
+	Branch      string     `json:"branch"`
+	PullRequest string     `json:"pull_request"`
+	BuildURL    string     `json:"build_url"`
+	Author      string     `json:"author"`
+	BuiltAt     *time.Time `json:"built_at"`
+}
+
+// parseReportMetadata loads and validates the metadata fields of a report upload form.
+func parseReportMetadata(form url.Values) (*reportMetadata, error) {
+	metadata := &reportMetadata{
+		Commit:      form.Get(constants.ReportCommitBodyParam),
+		Branch:      form.Get(constants.ReportBranchBodyParam),
+		PullRequest: form.Get(constants.ReportPullRequestBodyParam),
+		BuildURL:    form.Get(constants.ReportBuildURLBodyParam),
+		Author:      form.Get(constants.ReportAuthorBodyParam),
+	}
+
+	if metadata.PullRequest != "" {
+		if _, e := strconv.ParseUint(metadata.PullRequest, 10, 64); e != nil {
+			return nil, fmt.Errorf("invalid-pull-request")
+		}
+	}
+
+	if metadata.BuildURL != "" {
+		if u, e := url.Parse(metadata.BuildURL); e != nil || u.IsAbs() != true {
+			return nil, fmt.Errorf("invalid-build-url")
+		}
+	}
+
+	if value := form.Get(constants.ReportTimestampBodyParam); value != "" {
+		timestamp, e := parseReportTimestamp(value)
+
+		if e != nil {
+			return nil, fmt.Errorf("invalid-timestamp")
+		}
+
+		metadata.BuiltAt = &timestamp
+	}
+
+	return metadata, nil
+}
+
+// parseReportTimestamp accepts either an RFC3339 timestamp or the amount of seconds since the unix epoch.
+func parseReportTimestamp(value string) (time.Time, error) {
+	if seconds, e := strconv.ParseInt(value, 10, 64); e == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// apply copies the metadata onto the report record. Reports uploaded without a build timestamp are considered built
+// when they were created, so a zero timestamp is never persisted.
+func (m *reportMetadata) apply(report *models.Report) {
+	report.Commit = m.Commit
+	report.Branch = m.Branch
+	report.PullRequest = m.PullRequest
+	report.BuildURL = m.BuildURL
+	report.Author = m.Author
+
+	report.BuiltAt = report.CreatedAt
+
+	if m.BuiltAt != nil {
+		report.BuiltAt = *m.BuiltAt
+	}
+}
+
+// metadataOf returns the metadata that was stored on the report record.
+func metadataOf(report *models.Report) reportMetadata {
+	metadata := reportMetadata{
+		Commit:      report.Commit,
+		Branch:      report.Branch,
+		PullRequest: report.PullRequest,
+		BuildURL:    report.BuildURL,
+		Author:      report.Author,
+	}
+
+	if report.BuiltAt.IsZero() != true {
+		builtAt := report.BuiltAt
+		metadata.BuiltAt = &builtAt
+	}
+
+	return metadata
+}
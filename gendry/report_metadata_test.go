@@ -0,0 +1,83 @@
+package gendry
+
+import "time"
+import "testing"
+import "net/url"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_ReportMetadata(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("parseReportMetadata", func() {
+		var form url.Values
+
+		g.BeforeEach(func() {
+			form = url.Values{
+				"commit":       []string{"abc123"},
+				"branch":       []string{"master"},
+				"pull_request": []string{"12"},
+				"build_url":    []string{"https://ci.example.com/builds/1"},
+				"author":       []string{"danny"},
+				"timestamp":    []string{"2017-12-04T10:00:00Z"},
+			}
+		})
+
+		g.It("returns empty metadata if no fields were provided", func() {
+			m, e := parseReportMetadata(url.Values{})
+			g.Assert(e).Equal(nil)
+			g.Assert(m.Commit).Equal("")
+			g.Assert(m.BuiltAt == nil).Equal(true)
+		})
+
+		g.It("loads every field from the form", func() {
+			m, e := parseReportMetadata(form)
+			g.Assert(e).Equal(nil)
+			g.Assert(m.Commit).Equal("abc123")
+			g.Assert(m.Branch).Equal("master")
+			g.Assert(m.PullRequest).Equal("12")
+			g.Assert(m.Author).Equal("danny")
+			g.Assert(m.BuiltAt.Equal(time.Date(2017, 12, 4, 10, 0, 0, 0, time.UTC))).Equal(true)
+		})
+
+		g.It("accepts unix timestamps", func() {
+			form.Set("timestamp", "1512381600")
+			m, e := parseReportMetadata(form)
+			g.Assert(e).Equal(nil)
+			g.Assert(m.BuiltAt.Equal(time.Date(2017, 12, 4, 10, 0, 0, 0, time.UTC))).Equal(true)
+		})
+
+		g.It("returns an error for non-numeric pull requests", func() {
+			form.Set("pull_request", "abc")
+			_, e := parseReportMetadata(form)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for relative build urls", func() {
+			form.Set("build_url", "/builds/1")
+			_, e := parseReportMetadata(form)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns an error for invalid timestamps", func() {
+			form.Set("timestamp", "yesterday")
+			_, e := parseReportMetadata(form)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("round trips through a report record", func() {
+			m, _ := parseReportMetadata(form)
+			report := &models.Report{}
+			m.apply(report)
+			g.Assert(metadataOf(report)).Equal(*m)
+		})
+
+		g.It("considers reports uploaded without a timestamp built when they were created", func() {
+			form.Del("timestamp")
+			m, _ := parseReportMetadata(form)
+			report := &models.Report{CreatedAt: time.Date(2017, 12, 4, 10, 0, 0, 0, time.UTC)}
+			m.apply(report)
+			g.Assert(report.BuiltAt.Equal(report.CreatedAt)).Equal(true)
+		})
+	})
+}
@@ -1,121 +1,242 @@
 package gendry
 
 import "fmt"
-import "log"
+import "sort"
+import "bytes"
 import "regexp"
 import "strings"
 import "net/url"
 import "net/http"
+import "text/tabwriter"
 
 // Action types represent a single http request handler, wearere the last url.Values parameter contains path params.
 type Action func(http.ResponseWriter, *http.Request, url.Values)
 
-// APIEndpoint represents a single route that is can respond to various HTTP methods.
+// APIEndpoint represents a single route that is can respond to various HTTP methods. Every endpoint responds to GET
+// (and HEAD) requests; support for other methods is added by implementing the optional endpoint interfaces below.
 type APIEndpoint interface {
 	Get(http.ResponseWriter, *http.Request, url.Values)
+}
+
+// PostEndpoint is implemented by endpoints that respond to POST requests.
+type PostEndpoint interface {
 	Post(http.ResponseWriter, *http.Request, url.Values)
+}
+
+// PutEndpoint is implemented by endpoints that respond to PUT requests.
+type PutEndpoint interface {
+	Put(http.ResponseWriter, *http.Request, url.Values)
+}
+
+// PatchEndpoint is implemented by endpoints that respond to PATCH requests.
+type PatchEndpoint interface {
+	Patch(http.ResponseWriter, *http.Request, url.Values)
+}
+
+// DeleteEndpoint is implemented by endpoints that respond to DELETE requests.
+type DeleteEndpoint interface {
 	Delete(http.ResponseWriter, *http.Request, url.Values)
 }
 
-// RouteList is map of path expressions and their endpoints; matches an incoming request to a single action.
-type RouteList map[*regexp.Regexp]APIEndpoint
+// Route is a single entry of a route list; routes with a higher priority are matched first.
+type Route struct {
+	Priority   int
+	Expression *regexp.Regexp
+	Endpoint   APIEndpoint
+}
+
+// RouteList is an ordered list of path expressions and their endpoints; matches an incoming request to a single action
+// by trying each route in order of priority (most specific first), the first matching route winning.
+type RouteList struct {
+	routes []*Route
+}
+
+// NewRouteList returns an empty route list.
+func NewRouteList() *RouteList {
+	return &RouteList{routes: make([]*Route, 0)}
+}
 
+// actionFor returns the endpoint's action for the method; HEAD and OPTIONS requests are answered on behalf of every
+// endpoint while methods the endpoint does not implement are answered with a 405.
 func (l *RouteList) actionFor(method string, endpoint APIEndpoint) Action {
 	switch strings.ToUpper(method) {
-	case "POST":
-		return endpoint.Post
-	case "DELETE":
-		return endpoint.Delete
-	default:
+	case http.MethodGet:
 		return endpoint.Get
+	case http.MethodHead:
+		return headAction(endpoint.Get)
+	case http.MethodOptions:
+		return optionsAction(allowedMethods(endpoint))
+	case http.MethodPost:
+		if e, ok := endpoint.(PostEndpoint); ok {
+			return e.Post
+		}
+	case http.MethodPut:
+		if e, ok := endpoint.(PutEndpoint); ok {
+			return e.Put
+		}
+	case http.MethodPatch:
+		if e, ok := endpoint.(PatchEndpoint); ok {
+			return e.Patch
+		}
+	case http.MethodDelete:
+		if e, ok := endpoint.(DeleteEndpoint); ok {
+			return e.Delete
+		}
 	}
+
+	return methodNotAllowedAction(allowedMethods(endpoint))
 }
 
-func (l *RouteList) add(expression *regexp.Regexp, handler APIEndpoint) error {
+// Add registers the endpoint under the path expression. Every route must have a distinct expression and priority so
+// that the order routes are tried in never depends on the order they were registered in.
+func (l *RouteList) Add(priority int, expression string, endpoint APIEndpoint) error {
 	if l == nil {
 		return fmt.Errorf("invalid-route-list")
 	}
 
-	(*l)[expression] = handler
+	re, e := regexp.Compile(expression)
+
+	if e != nil {
+		return fmt.Errorf("invalid-route: %s (%v)", expression, e)
+	}
+
+	for _, existing := range l.routes {
+		if existing.Expression.String() == expression {
+			return fmt.Errorf("duplicate-route: %s", expression)
+		}
+
+		if existing.Priority == priority {
+			return fmt.Errorf(
+				"conflicting-route: %s and %s share priority %d", existing.Expression, expression, priority,
+			)
+		}
+	}
+
+	l.routes = append(l.routes, &Route{Priority: priority, Expression: re, Endpoint: endpoint})
+	sort.SliceStable(l.routes, func(i, j int) bool { return l.routes[i].Priority > l.routes[j].Priority })
 	return nil
 }
 
+// Routes returns the registered routes in the order they are matched.
+func (l *RouteList) Routes() []Route {
+	if l == nil {
+		return nil
+	}
+
+	results := make([]Route, len(l.routes))
+
+	for i, r := range l.routes {
+		results[i] = *r
+	}
+
+	return results
+}
+
+// String returns the route table, one route per line in the order they are matched, for debugging.
+func (l *RouteList) String() string {
+	output := new(bytes.Buffer)
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+
+	for _, r := range l.Routes() {
+		fmt.Fprintf(writer, "%d\t%s\t%T\n", r.Priority, r.Expression, r.Endpoint)
+	}
+
+	writer.Flush()
+	return output.String()
+}
+
 // Match performs a lookup based on a given http.Reqest record, returning the action associated w/ the path/method.
 func (l *RouteList) Match(request *http.Request) (Action, url.Values, bool) {
-	path := []byte(request.URL.EscapedPath())
+	route, groups := l.find(request.URL.EscapedPath())
 
-	if l == nil {
+	if route == nil {
 		return nil, nil, false
 	}
 
-	var fallback Action
+	names := route.Expression.SubexpNames()
+	params := make(url.Values)
 
-	for re, handler := range *l {
-		if match := re.Match(path); match != true {
+	for indx, v := range groups[1:] {
+		if name := names[indx+1]; name != "" {
+			params.Set(name, v)
 			continue
 		}
 
-		if s := re.NumSubexp(); s == 0 {
-			fallback = l.actionFor(request.Method, handler)
-			continue
-		}
+		params.Set(fmt.Sprintf("$%d", indx), v)
+	}
 
-		groups := re.FindAllStringSubmatch(string(path), -1)
-		names := re.SubexpNames()
+	return l.actionFor(request.Method, route.Endpoint), params, true
+}
+
+// find returns the first route (in order of priority) matching the path along with the expression's submatches.
+func (l *RouteList) find(path string) (*Route, []string) {
+	if l == nil {
+		return nil, nil
+	}
 
-		if groups == nil || len(groups) != 1 {
-			return l.actionFor(request.Method, handler), make(url.Values), true
+	for _, route := range l.routes {
+		if groups := route.Expression.FindStringSubmatch(path); groups != nil {
+			return route, groups
 		}
+	}
 
-		values := groups[0][1:]
-		params := make(url.Values)
-		count := len(names)
+	return nil, nil
+}
 
-		if count >= 0 {
-			names = names[1:]
-			count = len(names)
-		}
+type jsonResponse struct {
+	Errors []string `json:"errors"`
+}
 
-		for indx, v := range values {
-			if indx < count && len(names[indx]) >= 1 {
-				params.Set(names[indx], v)
-				continue
-			}
+// allowedMethods returns the http methods the endpoint responds to, as listed in the Allow header.
+func allowedMethods(endpoint APIEndpoint) []string {
+	methods := []string{http.MethodGet, http.MethodHead}
 
-			params.Set(fmt.Sprintf("$%d", indx), v)
-		}
+	if _, ok := endpoint.(PostEndpoint); ok {
+		methods = append(methods, http.MethodPost)
+	}
 
-		return l.actionFor(request.Method, handler), params, true
+	if _, ok := endpoint.(PutEndpoint); ok {
+		methods = append(methods, http.MethodPut)
 	}
 
-	if fallback != nil {
-		return fallback, make(url.Values), true
+	if _, ok := endpoint.(PatchEndpoint); ok {
+		methods = append(methods, http.MethodPatch)
 	}
 
-	return nil, nil, false
-}
+	if _, ok := endpoint.(DeleteEndpoint); ok {
+		methods = append(methods, http.MethodDelete)
+	}
 
-type jsonResponse struct {
-	Errors []string `json:"errors"`
+	return append(methods, http.MethodOptions)
 }
 
-type notImplementedRoute struct {
+func methodNotAllowedAction(methods []string) Action {
+	return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+		writer.Header().Set("Allow", strings.Join(methods, ", "))
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(writer, "method-not-allowed")
+	}
 }
 
-func (r notImplementedRoute) notImplemented(writer http.ResponseWriter, request *http.Request) {
-	log.Printf("not implemented: %s %v", request.Method, request.URL.Path)
-	writer.WriteHeader(400)
-	fmt.Fprintf(writer, "not-implemented")
+func optionsAction(methods []string) Action {
+	return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+		writer.Header().Set("Allow", strings.Join(methods, ", "))
+		writer.WriteHeader(http.StatusNoContent)
+	}
 }
 
-func (r notImplementedRoute) Delete(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	r.notImplemented(writer, request)
+// headAction runs the GET action, keeping the headers it writes but discarding its body.
+func headAction(get Action) Action {
+	return func(writer http.ResponseWriter, request *http.Request, params url.Values) {
+		get(headWriter{writer}, request, params)
+	}
 }
 
-func (r notImplementedRoute) Post(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	r.notImplemented(writer, request)
+// headWriter is a response writer that drops everything written to the body.
+type headWriter struct {
+	http.ResponseWriter
 }
 
-func (r notImplementedRoute) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
-	r.notImplemented(writer, request)
+func (w headWriter) Write(data []byte) (int, error) {
+	return len(data), nil
 }
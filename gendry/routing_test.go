@@ -2,12 +2,13 @@ package gendry
 
 import "io"
 import "bytes"
-import "regexp"
+import "strings"
 import "net/url"
 import "testing"
 import "net/http"
 import "net/http/httptest"
 import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/constants"
 
 type testRoute struct {
 	output io.Reader
@@ -25,6 +26,16 @@ func (r *testRoute) Post(writer http.ResponseWriter, request *http.Request, para
 	r.respond(writer, request, params)
 }
 
+// testReadOnlyRoute only responds to GET requests.
+type testReadOnlyRoute struct {
+}
+
+func (r *testReadOnlyRoute) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	writer.Header().Set("Content-Type", "text/plain")
+	writer.WriteHeader(200)
+	io.WriteString(writer, "read-only")
+}
+
 func (r *testRoute) respond(writer http.ResponseWriter, request *http.Request, params url.Values) {
 	writer.WriteHeader(200)
 
@@ -48,7 +59,7 @@ func Test_RouteList(t *testing.T) {
 
 		g.BeforeEach(func() {
 			route = &testRoute{}
-			routes = &RouteList{}
+			routes = NewRouteList()
 		})
 
 		g.It("returns false if no route found", func() {
@@ -58,7 +69,7 @@ func Test_RouteList(t *testing.T) {
 		})
 
 		g.It("returns true if route found, with an empty path param map", func() {
-			routes.add(regexp.MustCompile("^/bad-route"), route)
+			routes.Add(0, "^/bad-route", route)
 			request := httptest.NewRequest("GET", "/bad-route", new(bytes.Buffer))
 			_, values, found := routes.Match(request)
 			g.Assert(found).Equal(true)
@@ -66,7 +77,7 @@ func Test_RouteList(t *testing.T) {
 		})
 
 		g.It("returns true if route found, with unnamed path param matches in the value list", func() {
-			routes.add(regexp.MustCompile("^/bad-route/(.*)"), route)
+			routes.Add(0, "^/bad-route/(.*)", route)
 			request := httptest.NewRequest("GET", "/bad-route/213", new(bytes.Buffer))
 			_, values, found := routes.Match(request)
 			g.Assert(found).Equal(true)
@@ -75,12 +86,116 @@ func Test_RouteList(t *testing.T) {
 		})
 
 		g.It("returns true if route found, with named path param matches in the value list", func() {
-			routes.add(regexp.MustCompile("^/bad-route/(?P<uuid>.*)"), route)
+			routes.Add(0, "^/bad-route/(?P<uuid>.*)", route)
 			request := httptest.NewRequest("GET", "/bad-route/213", new(bytes.Buffer))
 			_, values, found := routes.Match(request)
 			g.Assert(found).Equal(true)
 			g.Assert(len(values)).Equal(1)
 			g.Assert(values.Get("uuid")).Equal("213")
 		})
+
+		g.It("returns an error for invalid expressions", func() {
+			g.Assert(routes.Add(0, "^/bad-route/(", route) == nil).Equal(false)
+		})
+
+		g.It("returns an error when an expression is registered twice", func() {
+			g.Assert(routes.Add(0, "^/reports", route)).Equal(nil)
+			g.Assert(routes.Add(1, "^/reports", route) == nil).Equal(false)
+		})
+
+		g.It("returns an error when two routes share a priority", func() {
+			g.Assert(routes.Add(0, "^/reports", route)).Equal(nil)
+			g.Assert(routes.Add(0, "^/projects", route) == nil).Equal(false)
+		})
+
+		g.It("matches routes in order of priority regardless of registration order", func() {
+			fallback, specific := &testRoute{}, &testRoute{}
+			routes.Add(0, "^/reports", fallback)
+			routes.Add(10, "^/reports/(?P<project>[^/]+)/(?P<tag>[^/]+)\\.svg$", specific)
+
+			for i := 0; i < 10; i++ {
+				action, params, found := routes.Match(httptest.NewRequest("GET", "/reports/gendry/master.svg", nil))
+				g.Assert(found).Equal(true)
+				action(httptest.NewRecorder(), nil, params)
+				g.Assert(params.Get("tag")).Equal("master")
+			}
+
+			g.Assert(len(specific.params)).Equal(10)
+			g.Assert(len(fallback.params)).Equal(0)
+
+			action, params, _ := routes.Match(httptest.NewRequest("GET", "/reports", nil))
+			action(httptest.NewRecorder(), nil, params)
+			g.Assert(len(fallback.params)).Equal(1)
+		})
+
+		g.Describe("method dispatch", func() {
+			var readOnly *testReadOnlyRoute
+
+			g.BeforeEach(func() {
+				readOnly = &testReadOnlyRoute{}
+				routes.Add(10, "^/read-only", readOnly)
+				routes.Add(0, "^/test", route)
+			})
+
+			serve := func(method string, path string) *httptest.ResponseRecorder {
+				request := httptest.NewRequest(method, path, nil)
+				action, params, found := routes.Match(request)
+				g.Assert(found).Equal(true)
+				recorder := httptest.NewRecorder()
+				action(recorder, request, params)
+				return recorder
+			}
+
+			g.It("responds with a 405 and the allowed methods for methods the endpoint does not implement", func() {
+				recorder := serve("POST", "/read-only")
+				g.Assert(recorder.Code).Equal(405)
+				g.Assert(recorder.Header().Get("Allow")).Equal("GET, HEAD, OPTIONS")
+			})
+
+			g.It("responds with a 405 for unknown methods", func() {
+				recorder := serve("TRACE", "/test")
+				g.Assert(recorder.Code).Equal(405)
+				g.Assert(recorder.Header().Get("Allow")).Equal("GET, HEAD, POST, DELETE, OPTIONS")
+			})
+
+			g.It("answers OPTIONS requests with the allowed methods", func() {
+				recorder := serve("OPTIONS", "/read-only")
+				g.Assert(recorder.Code).Equal(204)
+				g.Assert(recorder.Header().Get("Allow")).Equal("GET, HEAD, OPTIONS")
+			})
+
+			g.It("answers HEAD requests with the headers of the GET action but no body", func() {
+				recorder := serve("HEAD", "/read-only")
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Header().Get("Content-Type")).Equal("text/plain")
+				g.Assert(recorder.Body.Len()).Equal(0)
+			})
+
+			g.It("responds with a 405 for report comparisons requested with methods other than GET", func() {
+				routes.Add(20, constants.ReportCompareAPIRegex, NewReportCompareAPI(nil, nil, nil, &testLogger{}))
+
+				for _, method := range []string{"POST", "PATCH", "DELETE"} {
+					recorder := serve(method, "/reports/compare")
+					g.Assert(recorder.Code).Equal(405)
+					g.Assert(recorder.Header().Get("Allow")).Equal("GET, HEAD, OPTIONS")
+				}
+			})
+
+			g.It("dispatches implemented methods to the endpoint", func() {
+				route.output = strings.NewReader("posted")
+				recorder := serve("POST", "/test")
+				g.Assert(recorder.Code).Equal(200)
+				g.Assert(recorder.Body.String()).Equal("posted")
+			})
+		})
+
+		g.It("dumps the route table in the order routes are matched", func() {
+			routes.Add(0, "^/reports", route)
+			routes.Add(10, "^/projects", route)
+			lines := strings.Split(strings.TrimSpace(routes.String()), "\n")
+			g.Assert(len(lines)).Equal(2)
+			g.Assert(strings.Fields(lines[0])).Equal([]string{"10", "^/projects", "*gendry.testRoute"})
+			g.Assert(strings.Fields(lines[1])).Equal([]string{"0", "^/reports", "*gendry.testRoute"})
+		})
 	})
 }
@@ -1,29 +1,73 @@
 package gendry
 
 import "io"
+import "fmt"
+import "sync"
+import "time"
+import "context"
 import "strings"
+import "net/url"
 import "net/http"
+import "crypto/tls"
 
 // Runtime defines an interface that is used as the http runtime for the gendry api.
 type Runtime interface {
 	Start(string, chan<- error)
+	Shutdown(context.Context) error
+	Reload() error
 }
 
-// NewRuntime returns an initialized runtime using the provided route list.
-func NewRuntime(routes *RouteList, log LeveledLogger) Runtime {
+// RuntimeOptions configures the http server of the runtime; zero timeouts are disabled. The server listens with tls
+// when both a certificate and key file are provided.
+type RuntimeOptions struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	CertFile     string
+	KeyFile      string
+}
+
+// NewRuntime returns an initialized runtime using the provided route list; every request (including those not matching
+// a route) passes through the middleware, the first middleware being the outermost.
+func NewRuntime(routes *RouteList, options RuntimeOptions, log LeveledLogger, middleware ...Middleware) Runtime {
 	r := &runtime{
 		LeveledLogger: log,
 		routes:        routes,
+		options:       options,
+	}
+
+	r.handler = chain(r.dispatch, middleware...)
+
+	r.server = &http.Server{
+		Handler:      r,
+		ReadTimeout:  options.ReadTimeout,
+		WriteTimeout: options.WriteTimeout,
+		IdleTimeout:  options.IdleTimeout,
 	}
+
+	if r.secure() {
+		r.server.TLSConfig = &tls.Config{GetCertificate: r.certificate}
+	}
+
 	return r
 }
 
 type runtime struct {
 	LeveledLogger
-	routes *RouteList
+	sync.RWMutex
+	routes  *RouteList
+	handler Action
+	options RuntimeOptions
+	server  *http.Server
+	cert    *tls.Certificate
 }
 
 func (r *runtime) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	r.handler(responseWriter, request, nil)
+}
+
+// dispatch runs the action of the route matching the request.
+func (r *runtime) dispatch(responseWriter http.ResponseWriter, request *http.Request, _ url.Values) {
 	route, params, found := r.routes.Match(request)
 
 	if !found {
@@ -36,6 +80,70 @@ func (r *runtime) ServeHTTP(responseWriter http.ResponseWriter, request *http.Re
 	route(responseWriter, request, params)
 }
 
+// Start listens on the address until the runtime fails or is shut down, sending the failure (nil after a shutdown)
+// on the closed channel. Runtimes given only one of the certificate and key files fail to start.
 func (r *runtime) Start(addr string, closed chan<- error) {
-	closed <- http.ListenAndServe(addr, r)
+	r.server.Addr = addr
+
+	if (r.options.CertFile == "") != (r.options.KeyFile == "") {
+		closed <- fmt.Errorf("tls requires both a certificate and a key file")
+		return
+	}
+
+	if r.secure() != true {
+		closed <- r.serveResult(r.server.ListenAndServe())
+		return
+	}
+
+	if e := r.Reload(); e != nil {
+		closed <- e
+		return
+	}
+
+	closed <- r.serveResult(r.server.ListenAndServeTLS("", ""))
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to complete, giving up once the context is
+// done.
+func (r *runtime) Shutdown(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
+// Reload loads the tls certificate and key files again so certificates can be rotated without a restart; the current
+// certificate is kept when the files can not be loaded.
+func (r *runtime) Reload() error {
+	if r.secure() != true {
+		return nil
+	}
+
+	cert, e := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+
+	if e != nil {
+		return e
+	}
+
+	r.Lock()
+	r.cert = &cert
+	r.Unlock()
+
+	r.Infof("loaded tls certificate %s", r.options.CertFile)
+	return nil
+}
+
+func (r *runtime) certificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.cert, nil
+}
+
+func (r *runtime) secure() bool {
+	return r.options.CertFile != "" && r.options.KeyFile != ""
+}
+
+func (r *runtime) serveResult(e error) error {
+	if e == http.ErrServerClosed {
+		return nil
+	}
+
+	return e
 }
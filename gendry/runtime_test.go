@@ -0,0 +1,116 @@
+package gendry
+
+import "net"
+import "time"
+import "context"
+import "net/url"
+import "testing"
+import "net/http"
+import "io/ioutil"
+import "github.com/franela/goblin"
+
+// testBlockingRoute responds once it has been released, allowing requests to be kept in flight.
+type testBlockingRoute struct {
+	started  chan bool
+	released chan bool
+}
+
+func (r *testBlockingRoute) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	r.started <- true
+	<-r.released
+	writer.Write([]byte("done"))
+}
+
+func Test_Runtime(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("runtime", func() {
+		var route *testBlockingRoute
+		var server Runtime
+		var closed chan error
+		var address string
+
+		g.BeforeEach(func() {
+			listener, _ := net.Listen("tcp", "127.0.0.1:0")
+			address = listener.Addr().String()
+			listener.Close()
+
+			route = &testBlockingRoute{started: make(chan bool, 1), released: make(chan bool)}
+			routes := NewRouteList()
+			routes.Add(0, "^/slow", route)
+
+			closed = make(chan error, 1)
+			server = NewRuntime(routes, RuntimeOptions{ReadTimeout: time.Second}, &testLogger{})
+			go server.Start(address, closed)
+
+			for i := 0; i < 50; i++ {
+				if connection, e := net.Dial("tcp", address); e == nil {
+					connection.Close()
+					break
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+
+		g.It("finishes in-flight requests before shutting down", func() {
+			responses := make(chan string, 1)
+
+			go func() {
+				response, e := http.Get("http://" + address + "/slow")
+
+				if e != nil {
+					responses <- e.Error()
+					return
+				}
+
+				defer response.Body.Close()
+				body, _ := ioutil.ReadAll(response.Body)
+				responses <- string(body)
+			}()
+
+			<-route.started
+			stopped := make(chan error, 1)
+			go func() { stopped <- server.Shutdown(context.Background()) }()
+
+			time.Sleep(50 * time.Millisecond)
+			route.released <- true
+
+			g.Assert(<-stopped).Equal(nil)
+			g.Assert(<-responses).Equal("done")
+			g.Assert(<-closed).Equal(nil)
+		})
+
+		g.It("gives up on in-flight requests once the deadline passes", func() {
+			go http.Get("http://" + address + "/slow")
+			<-route.started
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			g.Assert(server.Shutdown(ctx)).Equal(context.DeadlineExceeded)
+			route.released <- true
+		})
+
+		g.It("does not reload certificates when tls is not configured", func() {
+			g.Assert(server.Reload()).Equal(nil)
+			server.Shutdown(context.Background())
+		})
+	})
+
+	g.Describe("runtime with tls", func() {
+		g.It("fails to start when the certificate can not be loaded", func() {
+			options := RuntimeOptions{CertFile: "missing.crt", KeyFile: "missing.key"}
+			closed := make(chan error, 1)
+			NewRuntime(NewRouteList(), options, &testLogger{}).Start("127.0.0.1:0", closed)
+			g.Assert(<-closed == nil).Equal(false)
+		})
+
+		g.It("fails to start when only one of the certificate and key files is configured", func() {
+			closed := make(chan error, 1)
+			options := RuntimeOptions{CertFile: "server.crt"}
+			NewRuntime(NewRouteList(), options, &testLogger{}).Start("127.0.0.1:0", closed)
+			g.Assert(<-closed == nil).Equal(false)
+		})
+	})
+}
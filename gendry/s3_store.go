@@ -0,0 +1,370 @@
+package gendry
+
+import "io"
+import "os"
+import "log"
+import "fmt"
+import "time"
+import "path"
+import "strings"
+import "net/http"
+import "crypto/tls"
+import "github.com/satori/go.uuid"
+import "github.com/aws/aws-sdk-go/aws"
+import "github.com/aws/aws-sdk-go/service/s3"
+import "github.com/aws/aws-sdk-go/aws/session"
+import "github.com/aws/aws-sdk-go/aws/credentials"
+import "github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+import "github.com/dadleyy/gendry/gendry/models"
+
+// s3store persists files into an s3 bucket. The endpoint, addressing and tls options allow the store to be pointed
+// at s3-compatible object stores (e.g. minio or ceph) and the key prefix allows several deployments to share a bucket.
+type s3store struct {
+	accessID    string
+	accessToken string
+	accessKey   string
+	bucketName  string
+	region      string
+	endpoint    string
+	pathStyle   bool
+	skipVerify  bool
+	caBundle    string
+	keyPrefix   string
+	compression string
+	persistence models.FileStore
+
+	presignExpiry time.Duration
+}
+
+// s3File is the writer returned by the s3 store; encoded content is streamed through a pipe into the upload. Closing
+// the file waits for the upload (and the bookkeeping of its record) to finish, returning its result.
+type s3File struct {
+	*encodedWriter
+	pipe *io.PipeWriter
+	done chan error
+}
+
+func (f *s3File) Close() error {
+	if e := f.encodedWriter.Close(); e != nil {
+		f.pipe.CloseWithError(e)
+		<-f.done
+		return e
+	}
+
+	f.pipe.Close()
+	return <-f.done
+}
+
+func (s *s3store) NewFile(contentType string, directory string) (string, io.WriteCloser, error) {
+	uploadSession, e := s.newSession()
+
+	if e != nil {
+		return "", nil, e
+	}
+
+	uploader := s3manager.NewUploader(uploadSession)
+
+	pr, pw := io.Pipe()
+	id := fmt.Sprintf("%s", uuid.NewV4())
+
+	record := models.File{
+		SystemID:    id,
+		Status:      "PENDING",
+		CreatedAt:   time.Now(),
+		ContentType: contentType,
+		ObjectID:    id,
+		Encoding:    storedEncoding(s.compression),
+	}
+
+	if _, e := s.persistence.CreateFiles(record); e != nil {
+		return "", nil, e
+	}
+
+	file := &s3File{encodedWriter: newEncodedWriter(pw, record.Encoding), pipe: pw, done: make(chan error, 1)}
+
+	go func() {
+		file.done <- s.upload(uploader, uploadSession, pr, path.Join(directory, id), &record, file.encodedWriter)
+	}()
+
+	return id, file, nil
+}
+
+// upload streams the content read from the pipe into the object, recording the content once it has been stored. The
+// pipe is closed with any failure so writes to the file stop blocking.
+func (s *s3store) upload(
+	uploader *s3manager.Uploader, uploadSession *session.Session, pr *io.PipeReader, name string, record *models.File,
+	content *encodedWriter,
+) error {
+	key := s.key(name)
+	id := record.SystemID
+
+	input := &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(record.ContentType),
+		Body:        pr,
+	}
+
+	if _, e := uploader.Upload(input); e != nil {
+		e = fmt.Errorf("unable to put object into s3 (error: %v)", e)
+		pr.CloseWithError(e)
+		return e
+	}
+
+	object, e := recordContent(s.persistence, id, record.Encoding, content.checksum)
+
+	if e != nil {
+		pr.CloseWithError(e)
+		return e
+	}
+
+	// Identical content is already stored; the record now references that content so this copy is discarded.
+	if object != id {
+		input := &s3.DeleteObjectInput{Bucket: aws.String(s.bucketName), Key: aws.String(key)}
+		s3.New(uploadSession).DeleteObject(input)
+	}
+
+	if e := validateFile(s.persistence, id); e != nil {
+		pr.CloseWithError(e)
+		return e
+	}
+
+	pr.Close()
+	return nil
+}
+
+// FindFile streams the original content of the object directly from s3, verified against its checksum.
+func (s *s3store) FindFile(filepath string) (io.ReadCloser, error) {
+	file, record, e := s.open(filepath, "")
+
+	if e != nil {
+		return nil, e
+	}
+
+	if record == nil {
+		return file, nil
+	}
+
+	decoded, e := decodeFile(file, record.Encoding)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return verifyFile(decoded, record), nil
+}
+
+// FindFileRange streams the requested byte range of the object as it is stored (i.e. possibly encoded); an empty range
+// streams the entire object.
+func (s *s3store) FindFileRange(filepath string, byteRange string) (*FileRange, error) {
+	file, record, e := s.open(filepath, byteRange)
+
+	if e != nil {
+		return nil, e
+	}
+
+	// Partial or encoded reads cannot be checked against the checksum of the file's original content.
+	if record != nil && record.Encoding == "" && byteRange == "" {
+		file.ReadCloser = verifyFile(file.ReadCloser, record)
+	}
+
+	if record != nil {
+		file.Encoding = record.Encoding
+	}
+
+	return file, nil
+}
+
+// open requests the object holding the file's content, returning it along with the file's record (if it has one).
+func (s *s3store) open(filepath string, byteRange string) (*FileRange, *models.File, error) {
+	downloadSession, e := s.newSession()
+
+	if e != nil {
+		return nil, nil, e
+	}
+
+	object, record := objectName(s.persistence, filepath)
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.key(object)),
+	}
+
+	if byteRange != "" {
+		input.Range = aws.String(byteRange)
+	}
+
+	output, e := s3.New(downloadSession).GetObject(input)
+
+	if failure, ok := e.(interface{ StatusCode() int }); ok && failure.StatusCode() == 416 {
+		return nil, nil, ErrInvalidRange
+	}
+
+	if e != nil {
+		log.Printf("unable to download from s3: %s", e)
+		return nil, nil, e
+	}
+
+	file := &FileRange{
+		ReadCloser:   output.Body,
+		ContentRange: aws.StringValue(output.ContentRange),
+		Length:       aws.Int64Value(output.ContentLength),
+	}
+
+	return file, record, nil
+}
+
+// SignFile issues a presigned url for the object holding the file's content, valid for the store's presign expiry.
+// Encoded objects are served with their content encoding, so they may only be signed for clients accepting it.
+func (s *s3store) SignFile(filepath string, encoded bool) (string, error) {
+	if s.presignExpiry <= 0 {
+		return "", ErrUnsignable
+	}
+
+	object, record := objectName(s.persistence, filepath)
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.key(object)),
+	}
+
+	if record != nil && record.ContentType != "" {
+		input.ResponseContentType = aws.String(record.ContentType)
+	}
+
+	if record != nil && record.Encoding != "" {
+		if encoded != true {
+			return "", ErrUnsignable
+		}
+
+		input.ResponseContentEncoding = aws.String(record.Encoding)
+	}
+
+	signSession, e := s.newSession()
+
+	if e != nil {
+		return "", e
+	}
+
+	request, _ := s3.New(signSession).GetObjectRequest(input)
+	return request.Presign(s.presignExpiry)
+}
+
+// DeleteFile removes the file's record, removing the object from the bucket once no other record references it.
+func (s *s3store) DeleteFile(filepath string) error {
+	deleteSession, e := s.newSession()
+
+	if e != nil {
+		return e
+	}
+
+	object, unreferenced, e := releaseFile(s.persistence, filepath)
+
+	if e != nil || unreferenced != true {
+		return e
+	}
+
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.key(object)),
+	}
+
+	_, e = s3.New(deleteSession).DeleteObject(input)
+	return e
+}
+
+// ListFiles lists the objects beneath the directory, naming them relative to the store's key prefix.
+func (s *s3store) ListFiles(directory string) ([]StoredFile, error) {
+	listSession, e := s.newSession()
+
+	if e != nil {
+		return nil, e
+	}
+
+	prefix := s.key(directory) + "/"
+	results := make([]StoredFile, 0)
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	page := func(output *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range output.Contents {
+			name := path.Join(directory, strings.TrimPrefix(aws.StringValue(object.Key), prefix))
+			results = append(results, StoredFile{Name: name, ModifiedAt: aws.TimeValue(object.LastModified)})
+		}
+
+		return true
+	}
+
+	if e := s3.New(listSession).ListObjectsV2Pages(input, page); e != nil {
+		return nil, e
+	}
+
+	return results, nil
+}
+
+// key returns the object key of the file, including the store's key prefix.
+func (s *s3store) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.keyPrefix, path.Clean("/"+name)), "/")
+}
+
+func (s *s3store) config() (*aws.Config, error) {
+	creds := credentials.NewStaticCredentials(s.accessID, s.accessKey, s.accessToken)
+
+	if _, e := creds.Get(); e != nil {
+		return nil, e
+	}
+
+	region := s.region
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	config := aws.NewConfig().WithRegion(region).WithCredentials(creds)
+
+	if s.endpoint != "" {
+		config = config.WithEndpoint(s.endpoint)
+	}
+
+	if s.pathStyle {
+		config = config.WithS3ForcePathStyle(true)
+	}
+
+	if s.skipVerify {
+		transport := &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+
+		config = config.WithHTTPClient(&http.Client{Transport: transport})
+	}
+
+	return config, nil
+}
+
+func (s *s3store) newSession() (*session.Session, error) {
+	config, e := s.config()
+
+	if e != nil {
+		return nil, e
+	}
+
+	options := session.Options{Config: *config}
+
+	if s.caBundle != "" {
+		bundle, e := os.Open(s.caBundle)
+
+		if e != nil {
+			return nil, e
+		}
+
+		defer bundle.Close()
+		options.CustomCABundle = bundle
+	}
+
+	return session.NewSessionWithOptions(options)
+}
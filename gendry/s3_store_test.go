@@ -0,0 +1,90 @@
+package gendry
+
+import "time"
+import "testing"
+import "github.com/franela/goblin"
+import "github.com/aws/aws-sdk-go/aws"
+import "github.com/dadleyy/gendry/gendry/models"
+
+func Test_S3Store(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("s3store", func() {
+		var store *s3store
+
+		g.BeforeEach(func() {
+			store = &s3store{accessID: "id", accessKey: "key"}
+		})
+
+		g.Describe("key", func() {
+			g.It("returns the path unchanged without a prefix", func() {
+				g.Assert(store.key("reports/abc")).Equal("reports/abc")
+			})
+
+			g.It("prepends the key prefix", func() {
+				store.keyPrefix = "/gendry/staging/"
+				g.Assert(store.key("reports/abc")).Equal("gendry/staging/reports/abc")
+			})
+
+			g.It("does not allow the path to escape the prefix", func() {
+				store.keyPrefix = "gendry"
+				g.Assert(store.key("../../reports/abc")).Equal("gendry/reports/abc")
+			})
+		})
+
+		g.Describe("config", func() {
+			g.It("defaults to the us-east-1 region and the aws endpoint", func() {
+				config, e := store.config()
+				g.Assert(e).Equal(nil)
+				g.Assert(aws.StringValue(config.Region)).Equal("us-east-1")
+				g.Assert(config.Endpoint == nil).Equal(true)
+				g.Assert(config.S3ForcePathStyle == nil).Equal(true)
+				g.Assert(config.HTTPClient == nil).Equal(true)
+			})
+
+			g.It("uses the custom endpoint with path-style addressing", func() {
+				store.endpoint, store.pathStyle = "http://127.0.0.1:9000", true
+				config, e := store.config()
+				g.Assert(e).Equal(nil)
+				g.Assert(aws.StringValue(config.Endpoint)).Equal("http://127.0.0.1:9000")
+				g.Assert(*config.S3ForcePathStyle).Equal(true)
+			})
+
+			g.It("uses an http client that skips tls verification when requested", func() {
+				store.skipVerify = true
+				config, e := store.config()
+				g.Assert(e).Equal(nil)
+				g.Assert(config.HTTPClient == nil).Equal(false)
+			})
+		})
+
+		g.Describe("SignFile", func() {
+			var persistence *testFilePersistence
+
+			g.BeforeEach(func() {
+				persistence = &testFilePersistence{files: map[string]*models.File{
+					"plain":      {SystemID: "plain", ContentType: "text/html"},
+					"compressed": {SystemID: "compressed", ContentType: "text/html", Encoding: "gzip"},
+				}}
+				store.persistence = persistence
+				store.presignExpiry = time.Minute
+			})
+
+			g.It("does not sign urls when presigning is disabled", func() {
+				store.presignExpiry = 0
+				_, e := store.SignFile("reports/plain", true)
+				g.Assert(e).Equal(ErrUnsignable)
+			})
+
+			g.It("does not sign urls of encoded files for clients that do not accept the encoding", func() {
+				_, e := store.SignFile("reports/compressed", false)
+				g.Assert(e).Equal(ErrUnsignable)
+			})
+
+			g.It("signs urls of encoded files for clients accepting the encoding", func() {
+				_, e := store.SignFile("reports/compressed", true)
+				g.Assert(e).Equal(nil)
+			})
+		})
+	})
+}
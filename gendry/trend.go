@@ -0,0 +1,72 @@
+package gendry
+
+import "fmt"
+import "time"
+import "github.com/dadleyy/gendry/gendry/models"
+
+// trendPoint summarizes the coverage of every report created within a single bucket of time. The Coverage value is
+// the coverage of the most recent report in the bucket.
+type trendPoint struct {
+	Time     time.Time `json:"time"`
+	Reports  int       `json:"reports"`
+	Coverage float64   `json:"coverage"`
+	Average  float64   `json:"average"`
+	Minimum  float64   `json:"minimum"`
+	Maximum  float64   `json:"maximum"`
+}
+
+// trendBuckets maps the supported bucket names to a function that truncates a time to the start of its bucket.
+var trendBuckets = map[string]func(time.Time) time.Time{
+	"report": func(t time.Time) time.Time {
+		return t
+	},
+	"day": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	},
+	"week": func(t time.Time) time.Time {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	},
+	"month": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	},
+}
+
+// coverageTrend groups reports (which must be sorted by creation time, oldest first) into buckets of time.
+func coverageTrend(reports []*models.Report, bucket string) ([]*trendPoint, error) {
+	truncate, ok := trendBuckets[bucket]
+
+	if !ok {
+		return nil, fmt.Errorf("invalid-bucket: %s", bucket)
+	}
+
+	results := make([]*trendPoint, 0)
+	var current *trendPoint
+	var sum float64
+
+	for _, r := range reports {
+		start := truncate(r.CreatedAt.UTC())
+
+		if current == nil || current.Time.Equal(start) != true {
+			current = &trendPoint{Time: start, Minimum: r.Coverage, Maximum: r.Coverage}
+			results = append(results, current)
+			sum = 0
+		}
+
+		sum += r.Coverage
+		current.Reports++
+		current.Coverage = r.Coverage
+		current.Average = sum / float64(current.Reports)
+
+		if r.Coverage < current.Minimum {
+			current.Minimum = r.Coverage
+		}
+
+		if r.Coverage > current.Maximum {
+			current.Maximum = r.Coverage
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,128 @@
+package gendry
+
+import "fmt"
+import "time"
+import "net/url"
+import "net/http"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+const (
+	defaultTrendBucket = "day"
+	defaultTrendWindow = time.Hour * 24 * 30
+)
+
+// NewTrendAPI returns an api that renders the coverage of a project's reports over time.
+func NewTrendAPI(re models.ReportStore, pr models.ProjectStore, log LeveledLogger) APIEndpoint {
+	api := &trendAPI{
+		LeveledLogger:   log,
+		reportAuthority: reportAuthority{projects: pr, reports: re},
+	}
+
+	return api
+}
+
+type trendAPI struct {
+	LeveledLogger
+	jsonResponder
+	reportAuthority
+}
+
+func (a *trendAPI) Get(writer http.ResponseWriter, request *http.Request, params url.Values) {
+	project, e := a.project(request)
+
+	if e != nil {
+		a.Warnf("unable to find project (error %s)", e.Error())
+		a.renderError(writer, "invalid-project")
+		return
+	}
+
+	projectID := params.Get(constants.ProjectIDParamName)
+
+	if project.SystemID != projectID && fmt.Sprintf("%d", project.ID) != projectID {
+		a.Warnf("requested project != authed (request: %s, auth: %d)", projectID, project.ID)
+		a.renderError(writer, "invalid-project")
+		return
+	}
+
+	query := request.URL.Query()
+	until, since := time.Now(), time.Now().Add(-defaultTrendWindow)
+
+	if value := query.Get(constants.TrendSinceParamName); value != "" {
+		if since, e = parseTrendTime(value, false); e != nil {
+			a.renderError(writer, "invalid-since")
+			return
+		}
+	}
+
+	if value := query.Get(constants.TrendUntilParamName); value != "" {
+		if until, e = parseTrendTime(value, true); e != nil {
+			a.renderError(writer, "invalid-until")
+			return
+		}
+	}
+
+	bucket := defaultTrendBucket
+
+	if value := query.Get(constants.TrendBucketParamName); value != "" {
+		bucket = value
+	}
+
+	blueprint := &models.ReportBlueprint{
+		ProjectID:      []string{project.SystemID},
+		CreatedAtRange: []time.Time{since, until},
+		OrderBy:        "created_at",
+		OrderDirection: "DESC",
+		Limit:          constants.MaxTrendReports,
+	}
+
+	if tag := query.Get(constants.TrendTagParamName); tag != "" {
+		blueprint.Tag = []string{tag}
+	}
+
+	reports, e := a.reports.FindReports(blueprint)
+
+	if e != nil {
+		a.Warnf("unable to find reports for project %s (error %v)", project.SystemID, e)
+		a.renderError(writer, "server-error")
+		return
+	}
+
+	// The most recent reports of the window are loaded; the trend is built from the oldest of them first.
+	window := make([]*models.Report, len(reports))
+
+	for i, r := range reports {
+		window[len(reports)-1-i] = r
+	}
+
+	points, e := coverageTrend(window, bucket)
+
+	if e != nil {
+		a.renderError(writer, "invalid-bucket")
+		return
+	}
+
+	results := make([]interface{}, len(points))
+
+	for i, p := range points {
+		results[i] = p
+	}
+
+	a.renderSuccess(writer, results...)
+}
+
+// parseTrendTime accepts either a full RFC3339 timestamp or a plain date. Plain dates are the start of the day, or the
+// end of the day when used as the end of a window, so reports created during that day are included.
+func parseTrendTime(value string, end bool) (time.Time, error) {
+	if t, e := time.Parse(time.RFC3339, value); e == nil {
+		return t, nil
+	}
+
+	date, e := time.Parse("2006-01-02", value)
+
+	if e != nil || end != true {
+		return date, e
+	}
+
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,116 @@
+package gendry
+
+import "time"
+import "strings"
+import "testing"
+import "net/url"
+import "net/http/httptest"
+import "github.com/franela/goblin"
+import "github.com/dadleyy/gendry/gendry/models"
+import "github.com/dadleyy/gendry/gendry/constants"
+
+func Test_Trend(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("coverageTrend", func() {
+		var reports []*models.Report
+
+		g.BeforeEach(func() {
+			reports = []*models.Report{
+				{Coverage: 50, CreatedAt: time.Date(2017, 12, 4, 10, 0, 0, 0, time.UTC)},
+				{Coverage: 70, CreatedAt: time.Date(2017, 12, 4, 12, 0, 0, 0, time.UTC)},
+				{Coverage: 60, CreatedAt: time.Date(2017, 12, 6, 12, 0, 0, 0, time.UTC)},
+				{Coverage: 80, CreatedAt: time.Date(2018, 1, 2, 12, 0, 0, 0, time.UTC)},
+			}
+		})
+
+		g.It("returns an error for unknown buckets", func() {
+			_, e := coverageTrend(reports, "fortnight")
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("returns a point per report when bucketed by report", func() {
+			points, e := coverageTrend(reports, "report")
+			g.Assert(e).Equal(nil)
+			g.Assert(len(points)).Equal(4)
+		})
+
+		g.It("groups reports by day, using the latest coverage as the bucket value", func() {
+			points, _ := coverageTrend(reports, "day")
+			g.Assert(len(points)).Equal(3)
+			g.Assert(points[0].Reports).Equal(2)
+			g.Assert(points[0].Coverage).Equal(float64(70))
+			g.Assert(points[0].Average).Equal(float64(60))
+			g.Assert(points[0].Minimum).Equal(float64(50))
+			g.Assert(points[0].Maximum).Equal(float64(70))
+		})
+
+		g.It("groups reports by week, starting on monday", func() {
+			points, _ := coverageTrend(reports, "week")
+			g.Assert(len(points)).Equal(2)
+			g.Assert(points[0].Time.Equal(time.Date(2017, 12, 4, 0, 0, 0, 0, time.UTC))).Equal(true)
+			g.Assert(points[0].Reports).Equal(3)
+		})
+
+		g.It("groups reports by month", func() {
+			points, _ := coverageTrend(reports, "month")
+			g.Assert(len(points)).Equal(2)
+			g.Assert(points[1].Time.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))).Equal(true)
+		})
+	})
+
+	g.Describe("parseTrendTime", func() {
+		g.It("parses plain dates and RFC3339 timestamps", func() {
+			_, e := parseTrendTime("2017-12-04", false)
+			g.Assert(e).Equal(nil)
+			_, e = parseTrendTime("2017-12-04T10:00:00Z", false)
+			g.Assert(e).Equal(nil)
+			_, e = parseTrendTime("yesterday", false)
+			g.Assert(e == nil).Equal(false)
+		})
+
+		g.It("treats plain dates ending a window as the end of the day", func() {
+			start, _ := parseTrendTime("2017-12-04", false)
+			end, _ := parseTrendTime("2017-12-04", true)
+			g.Assert(start.Equal(time.Date(2017, 12, 4, 0, 0, 0, 0, time.UTC))).Equal(true)
+			g.Assert(end.After(time.Date(2017, 12, 4, 23, 59, 59, 0, time.UTC))).Equal(true)
+			g.Assert(end.Before(time.Date(2017, 12, 5, 0, 0, 0, 0, time.UTC))).Equal(true)
+		})
+	})
+
+	g.Describe("TrendAPI", func() {
+		var api *trendAPI
+		var reports *testReportPersistence
+
+		g.BeforeEach(func() {
+			reports = &testReportPersistence{reports: []*models.Report{
+				{ProjectID: "project-1", Coverage: 50, CreatedAt: time.Date(2017, 12, 4, 12, 0, 0, 0, time.UTC)},
+				{ProjectID: "project-1", Coverage: 70, CreatedAt: time.Date(2017, 12, 6, 12, 0, 0, 0, time.UTC)},
+			}}
+
+			api = &trendAPI{LeveledLogger: &testLogger{}, reportAuthority: reportAuthority{
+				reports: reports,
+				projects: &testProjectPersistence{projects: []*models.Project{
+					{SystemID: "project-1", Token: "secret"},
+				}},
+			}}
+		})
+
+		g.It("queries the most recent reports of the window, building the trend oldest first", func() {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("GET", "/projects/project-1/trend?since=2017-12-01&until=2017-12-06", nil)
+			request.Header.Set(constants.ProjectAuthTokenAPIHeader, "secret")
+			api.Get(recorder, request, url.Values{constants.ProjectIDParamName: {"project-1"}})
+
+			g.Assert(recorder.Code).Equal(200)
+			g.Assert(reports.found.Limit).Equal(constants.MaxTrendReports)
+			g.Assert(reports.found.OrderDirection).Equal("DESC")
+			g.Assert(reports.found.CreatedAtRange[0].Equal(time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC))).Equal(true)
+			g.Assert(reports.found.CreatedAtRange[1].Day()).Equal(6)
+
+			first := strings.Index(recorder.Body.String(), "2017-12-04")
+			second := strings.Index(recorder.Body.String(), "2017-12-06")
+			g.Assert(first >= 0 && first < second).Equal(true)
+		})
+	})
+}
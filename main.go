@@ -4,8 +4,12 @@ import "os"
 import "io"
 import "fmt"
 import "flag"
-import "regexp"
+import "time"
+import "context"
+import "syscall"
+import "strconv"
 import "net/url"
+import "os/signal"
 import "log/syslog"
 import "database/sql"
 import "github.com/joho/godotenv"
@@ -22,6 +26,9 @@ const (
 	defaultDatbaseName = "gendry"
 	defaultDatbaseHost = "0.0.0.0"
 	defaultDatbasePort = "3306"
+	defaultFileStore   = "s3"
+	defaultStoreDir    = "./data"
+	databaseDriverName = "mysql-instrumented"
 )
 
 type environment func(string) string
@@ -40,6 +47,40 @@ type cliOptions struct {
 	awsAccessKey     string
 	awsAccessToken   string
 	awsBucketName    string
+	awsRegion        string
+	awsEndpoint      string
+	awsPathStyle     bool
+	awsSkipVerify    bool
+	awsCABundle      string
+	awsKeyPrefix     string
+	awsPresignExpiry time.Duration
+	fileStore        string
+	fileStoreDir     string
+	compression      string
+	memoryMaxBytes   string
+	memoryMaxFiles   string
+	gcInterval       time.Duration
+	gcMinimumAge     time.Duration
+	readTimeout      time.Duration
+	writeTimeout     time.Duration
+	idleTimeout      time.Duration
+	shutdownTimeout  time.Duration
+	tlsCertFile      string
+	tlsKeyFile       string
+}
+
+// databaseConfig returns the mysql connection config; time columns are parsed so they can be scanned into time.Time.
+func (o *cliOptions) databaseConfig() *mysql.Config {
+	config := &mysql.Config{
+		User:      o.databaseUsername,
+		Passwd:    o.databasePassword,
+		DBName:    o.databaseName,
+		Net:       "tcp",
+		Addr:      fmt.Sprintf("%s:%s", o.databaseHostname, o.databasePort),
+		ParseTime: true,
+	}
+
+	return config
 }
 
 func (o *cliOptions) env(env environment) error {
@@ -55,6 +96,91 @@ func (o *cliOptions) env(env environment) error {
 		o.awsBucketName = bucket
 	}
 
+	if region := env(constants.AWSRegionEnvVariable); region != "" {
+		o.awsRegion = region
+	}
+
+	if endpoint := env(constants.AWSEndpointEnvVariable); endpoint != "" {
+		o.awsEndpoint = endpoint
+	}
+
+	if pathStyle := env(constants.AWSForcePathStyleEnvVariable); pathStyle != "" {
+		o.awsPathStyle = pathStyle == "true"
+	}
+
+	if skip := env(constants.AWSSkipVerifyEnvVariable); skip != "" {
+		o.awsSkipVerify = skip == "true"
+	}
+
+	if bundle := env(constants.AWSCABundleEnvVariable); bundle != "" {
+		o.awsCABundle = bundle
+	}
+
+	if prefix := env(constants.AWSKeyPrefixEnvVariable); prefix != "" {
+		o.awsKeyPrefix = prefix
+	}
+
+	if expiry := env(constants.AWSPresignExpiryEnvVariable); expiry != "" {
+		duration, e := time.ParseDuration(expiry)
+
+		if e != nil {
+			return e
+		}
+
+		o.awsPresignExpiry = duration
+	}
+
+	if driver := env(constants.FileStoreDriverEnvVariable); driver != "" {
+		o.fileStore = driver
+	}
+
+	if dir := env(constants.FileStoreDirectoryEnvVariable); dir != "" {
+		o.fileStoreDir = dir
+	}
+
+	if compression := env(constants.FileStoreCompressionEnvVariable); compression != "" {
+		o.compression = compression
+	}
+
+	if cert := env(constants.TLSCertFileEnvVariable); cert != "" {
+		o.tlsCertFile = cert
+	}
+
+	if key := env(constants.TLSKeyFileEnvVariable); key != "" {
+		o.tlsKeyFile = key
+	}
+
+	timeouts := map[string]*time.Duration{
+		constants.ReadTimeoutEnvVariable:     &o.readTimeout,
+		constants.WriteTimeoutEnvVariable:    &o.writeTimeout,
+		constants.IdleTimeoutEnvVariable:     &o.idleTimeout,
+		constants.ShutdownTimeoutEnvVariable: &o.shutdownTimeout,
+	}
+
+	for key, timeout := range timeouts {
+		value := env(key)
+
+		if value == "" {
+			continue
+		}
+
+		duration, e := time.ParseDuration(value)
+
+		if e != nil {
+			return e
+		}
+
+		*timeout = duration
+	}
+
+	if maxBytes := env(constants.MemoryStoreMaxBytesEnvVariable); maxBytes != "" {
+		o.memoryMaxBytes = maxBytes
+	}
+
+	if maxFiles := env(constants.MemoryStoreMaxFilesEnvVariable); maxFiles != "" {
+		o.memoryMaxFiles = maxFiles
+	}
+
 	if port := env(constants.DatabasePortEnvVariable); port != "" {
 		o.databasePort = port
 	}
@@ -128,6 +254,61 @@ func logger(tag string) gendry.LeveledLogger {
 
 var logOuput io.Writer = os.Stdout
 
+// collect runs the file collector once on behalf of the "gc" subcommand, printing what was (or would be) removed.
+func collect(collector gendry.FileCollector, args []string, log gendry.LeveledLogger) {
+	commands := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := commands.Bool("dry-run", false, "report the files that would be removed without removing them")
+	commands.Parse(args)
+
+	result, e := collector.Collect(*dryRun)
+
+	if e != nil {
+		log.Errorf("unable to collect files: %s", e.Error())
+		return
+	}
+
+	groups := map[string][]string{
+		"pending":      result.Pending,
+		"unreferenced": result.Unreferenced,
+		"orphaned":     result.Orphaned,
+	}
+
+	for _, group := range []string{"pending", "unreferenced", "orphaned"} {
+		for _, name := range groups[group] {
+			fmt.Fprintf(os.Stdout, "%s\t%s\n", group, name)
+		}
+	}
+
+	log.Infof("collected %d files (dry run: %t)", result.Total(), result.DryRun)
+}
+
+// collectPeriodically runs the file collector on the interval until the done channel is closed.
+func collectPeriodically(
+	collector gendry.FileCollector, interval time.Duration, done <-chan struct{}, log gendry.LeveledLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		result, e := collector.Collect(false)
+
+		if e != nil {
+			log.Warnf("unable to collect files: %s", e.Error())
+			continue
+		}
+
+		if result.Total() > 0 {
+			log.Infof("collected %d files", result.Total())
+		}
+	}
+}
+
 func main() {
 	godotenv.Load()
 	options := cliOptions{}
@@ -166,6 +347,26 @@ func main() {
 	flag.StringVar(&options.awsAccessKey, "aws-access-key", "", "aws access key")
 	flag.StringVar(&options.awsAccessToken, "aws-access-token", "", "aws access token")
 	flag.StringVar(&options.awsBucketName, "aws-bucket-name", "", "aws access token")
+	flag.StringVar(&options.awsRegion, "aws-region", "", "aws region of the bucket (defaults to us-east-1)")
+	flag.StringVar(&options.awsEndpoint, "aws-endpoint", "", "custom s3-compatible endpoint url (e.g. minio)")
+	flag.BoolVar(&options.awsPathStyle, "aws-path-style", false, "use path-style addressing for s3 requests")
+	flag.BoolVar(&options.awsSkipVerify, "aws-insecure-skip-verify", false, "skip tls verification of the s3 endpoint")
+	flag.StringVar(&options.awsCABundle, "aws-ca-bundle", "", "path to a ca bundle used to verify the s3 endpoint")
+	flag.StringVar(&options.awsKeyPrefix, "aws-key-prefix", "", "prefix applied to every object key in the bucket")
+	flag.DurationVar(&options.awsPresignExpiry, "aws-presign-expiry", 0, "redirect to presigned urls valid this long")
+	flag.StringVar(&options.fileStore, "file-store", defaultFileStore, "report file store (s3, local, memory)")
+	flag.StringVar(&options.fileStoreDir, "file-store-directory", defaultStoreDir, "root of the local file store")
+	flag.StringVar(&options.compression, "file-store-compression", "gzip", "compression of stored reports (gzip, none)")
+	flag.StringVar(&options.memoryMaxBytes, "memory-store-max-bytes", "", "total size limit of the memory file store")
+	flag.StringVar(&options.memoryMaxFiles, "memory-store-max-files", "", "file count limit of the memory file store")
+	flag.DurationVar(&options.gcInterval, "gc-interval", 0, "how often to collect orphaned files (0 disables)")
+	flag.DurationVar(&options.gcMinimumAge, "gc-min-age", time.Hour, "minimum age of files considered by collection")
+	flag.DurationVar(&options.readTimeout, "read-timeout", 5*time.Minute, "maximum duration of reading a request")
+	flag.DurationVar(&options.writeTimeout, "write-timeout", 5*time.Minute, "maximum duration of writing a response")
+	flag.DurationVar(&options.idleTimeout, "idle-timeout", 2*time.Minute, "how long idle connections are kept")
+	flag.DurationVar(&options.shutdownTimeout, "shutdown-timeout", time.Minute, "how long in-flight requests may drain")
+	flag.StringVar(&options.tlsCertFile, "tls-cert", "", "tls certificate file, reloaded on SIGHUP (requires tls-key)")
+	flag.StringVar(&options.tlsKeyFile, "tls-key", "", "tls private key file, reloaded on SIGHUP (requires tls-cert)")
 	flag.Parse()
 
 	if options.address == "" {
@@ -177,15 +378,10 @@ func main() {
 		panic(e)
 	}
 
-	config := mysql.Config{
-		User:   options.databaseUsername,
-		Passwd: options.databasePassword,
-		DBName: options.databaseName,
-		Net:    "tcp",
-		Addr:   fmt.Sprintf("%s:%s", options.databaseHostname, options.databasePort),
-	}
+	metrics := gendry.NewMetrics()
+	sql.Register(databaseDriverName, gendry.InstrumentDriver(mysql.MySQLDriver{}, metrics))
 
-	db, e := sql.Open("mysql", config.FormatDSN())
+	db, e := sql.Open(databaseDriverName, options.databaseConfig().FormatDSN())
 
 	if e != nil {
 		log.Errorf("unable to connect to database: %s", e.Error())
@@ -197,34 +393,149 @@ func main() {
 		return
 	}
 
-	closed := make(chan error)
+	closed := make(chan error, 1)
 	fileStoreConfig := &url.Values{
 		constants.AWSAccessKeyEnvVariable:   []string{options.awsAccessKey},
 		constants.AWSAccessTokenEnvVariable: []string{options.awsAccessToken},
 		constants.AWSAccessKeyIDEnvVariable: []string{options.awsAccessKeyID},
 		constants.AWSBucketNameEnvVariable:  []string{options.awsBucketName},
+		constants.AWSRegionEnvVariable:      []string{options.awsRegion},
+		constants.AWSEndpointEnvVariable:    []string{options.awsEndpoint},
+		constants.AWSCABundleEnvVariable:    []string{options.awsCABundle},
+		constants.AWSKeyPrefixEnvVariable:   []string{options.awsKeyPrefix},
+
+		constants.AWSForcePathStyleEnvVariable: []string{strconv.FormatBool(options.awsPathStyle)},
+		constants.AWSSkipVerifyEnvVariable:     []string{strconv.FormatBool(options.awsSkipVerify)},
+		constants.AWSPresignExpiryEnvVariable:  []string{options.awsPresignExpiry.String()},
+
+		constants.FileStoreDirectoryEnvVariable:   []string{options.fileStoreDir},
+		constants.FileStoreCompressionEnvVariable: []string{options.compression},
+		constants.MemoryStoreMaxBytesEnvVariable:  []string{options.memoryMaxBytes},
+		constants.MemoryStoreMaxFilesEnvVariable:  []string{options.memoryMaxFiles},
 	}
 
 	ps := models.NewProjectStore(db)
 	rs := models.NewReportStore(db)
+	cs := models.NewCoverageFileStore(db)
+	fr := models.NewFileStore(db)
+
+	store, e := gendry.NewFileStore(options.fileStore, fileStoreConfig, db)
+
+	if e != nil {
+		log.Errorf("unable to create file store: %s", e.Error())
+		return
+	}
 
-	fs := gendry.NewFileStore("s3", fileStoreConfig, db)
+	fs := gendry.InstrumentFileStore(store, metrics)
 
 	defer db.Close()
 
-	badgeEndpoint := regexp.MustCompile(constants.DisplayAPIRegex)
+	collector := gendry.NewFileCollector(fr, rs, fs, options.gcMinimumAge, logger("file collector"))
+
+	if flag.Arg(0) == "gc" {
+		collect(collector, flag.Args()[1:], log)
+		return
+	}
 
-	routes := &gendry.RouteList{
-		badgeEndpoint:                    gendry.NewDisplayAPI(rs, ps, fs),
-		regexp.MustCompile("^/reports"):  gendry.NewReportAPI(rs, ps, fs, logger("report api")),
-		regexp.MustCompile("^/projects"): gendry.NewProjectAPI(ps, logger("projects api")),
+	if options.gcInterval > 0 {
+		stopped := make(chan struct{})
+		defer close(stopped)
+		go collectPeriodically(collector, options.gcInterval, stopped, logger("file collector"))
 	}
 
-	runtime := gendry.NewRuntime(routes, logger("runtime"))
+	reportAPI := gendry.NewReportAPI(rs, ps, cs, fs, metrics, logger("report api"))
+	routes := gendry.NewRouteList()
+
+	// Routes are matched in order of priority (most specific first); the report and project apis handle every other
+	// path beneath their prefix.
+	definitions := []struct {
+		priority   int
+		expression string
+		endpoint   gendry.APIEndpoint
+	}{
+		{80, constants.MetricsAPIRegex, gendry.NewMetricsAPI(metrics, logger("metrics api"))},
+		{70, constants.ReportCompareAPIRegex, gendry.NewReportCompareAPI(rs, ps, cs, logger("compare api"))},
+		{60, constants.ReportCoverageAPIRegex, gendry.NewReportCoverageAPI(rs, ps, cs, logger("coverage api"))},
+		{50, constants.ReportArtifactAPIRegex, gendry.NewReportArtifactAPI(rs, ps, fr, fs, logger("artifact api"))},
+		{40, constants.DisplayAPIRegex, gendry.NewDisplayAPI(rs, ps, fs, metrics)},
+		{30, constants.ProjectTrendAPIRegex, gendry.NewTrendAPI(rs, ps, logger("trend api"))},
+		{20, constants.ReportAPIRegex, reportAPI},
+		{10, constants.ProjectAPIRegex, gendry.NewProjectAPI(ps, rs, cs, fs, logger("projects api"))},
+	}
+
+	for _, d := range definitions {
+		if e := routes.Add(d.priority, d.expression, d.endpoint); e != nil {
+			log.Errorf("unable to register route: %s", e.Error())
+			return
+		}
+	}
+
+	log.Debugf("route table:\n%s", routes)
+
+	// The metrics and access log wrap the recovery so requests that panicked are recorded with the status they were
+	// answered with.
+	runtimeOptions := gendry.RuntimeOptions{
+		ReadTimeout:  options.readTimeout,
+		WriteTimeout: options.writeTimeout,
+		IdleTimeout:  options.idleTimeout,
+		CertFile:     options.tlsCertFile,
+		KeyFile:      options.tlsKeyFile,
+	}
+
+	runtime := gendry.NewRuntime(
+		routes,
+		runtimeOptions,
+		logger("runtime"),
+		gendry.RequestIDMiddleware(),
+		gendry.MetricsMiddleware(routes, metrics),
+		gendry.AccessLogMiddleware(logger("access")),
+		gendry.RecoveryMiddleware(logger("runtime")),
+		gendry.TimingMiddleware(),
+	)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go runtime.Start(options.address, closed)
 
 	log.Infof("server starting on %s", options.address)
-	<-closed
+	serve(runtime, closed, signals, options.shutdownTimeout, log)
 	log.Infof("server terminating")
 }
+
+// serve waits for the runtime to close, reloading its tls certificate on SIGHUP and shutting it down on SIGINT or
+// SIGTERM, allowing in-flight requests (e.g. report uploads) up to the timeout to finish.
+func serve(
+	runtime gendry.Runtime, closed <-chan error, signals <-chan os.Signal, timeout time.Duration,
+	log gendry.LeveledLogger,
+) {
+	for {
+		select {
+		case e := <-closed:
+			if e != nil {
+				log.Errorf("server closed: %s", e.Error())
+			}
+
+			return
+		case received := <-signals:
+			if received == syscall.SIGHUP {
+				if e := runtime.Reload(); e != nil {
+					log.Warnf("unable to reload tls certificate: %s", e.Error())
+				}
+
+				continue
+			}
+
+			log.Infof("received %s, draining requests for up to %v", received, timeout)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			e := runtime.Shutdown(ctx)
+			cancel()
+
+			if e != nil {
+				log.Warnf("unable to drain requests: %s", e.Error())
+			}
+
+			return
+		}
+	}
+}
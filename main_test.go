@@ -0,0 +1,17 @@
+package main
+
+import "strings"
+import "testing"
+import "github.com/franela/goblin"
+
+func Test_Main(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("cliOptions", func() {
+		g.It("parses the time columns of the database into time values", func() {
+			options := &cliOptions{databaseHostname: "0.0.0.0", databasePort: "3306"}
+			g.Assert(options.databaseConfig().ParseTime).Equal(true)
+			g.Assert(strings.Contains(options.databaseConfig().FormatDSN(), "parseTime=true")).Equal(true)
+		})
+	})
+}